@@ -4,6 +4,9 @@ package ast
 import (
 	"Chapter_2/token"
 	"bytes"
+	"math/big"
+	"strconv"
+	"strings"
 )
 
 // A Node can be a Statement or an Expression
@@ -22,6 +25,25 @@ type Expression interface {
 type Variable struct {
 	Token   token.Token // The VARIABLE token
 	Literal string
+	Binding *Binding // The binding the variable refers to, set by the resolver, nil before
+}
+
+// The kinds of storage a Binding can live in
+type BindingScope string
+
+const (
+	GlobalScope  BindingScope = "GLOBAL"  // declared at the top level of the program
+	LocalScope   BindingScope = "LOCAL"   // declared in a function, a block, a loop or a catch
+	FreeScope    BindingScope = "FREE"    // a local of an enclosing function, captured by a closure
+	BuiltinScope BindingScope = "BUILTIN" // provided by the interpreter
+)
+
+// A Binding contains
+type Binding struct {
+	Name        string
+	Scope       BindingScope
+	Index       int       // the slot of the binding among the bindings of its scope and function
+	Declaration *Variable // the Variable that declares the binding, nil for a builtin
 }
 
 func (variable *Variable) TokenLiteral() string { return variable.Token.Literal }
@@ -31,12 +53,72 @@ func (variable *Variable) expressionNode()      {}
 // An Integer is a type of Expression
 type IntegerLiteral struct {
 	Token token.Token // The Integer token
-	Value int64
+	Value int64       // The value when it fits in an int64
+	Big   *big.Int    // The value when it does not fit in an int64, nil otherwise
 }
 
 func (integerLiteral *IntegerLiteral) TokenLiteral() string { return integerLiteral.Token.Literal }
-func (integerLiteral *IntegerLiteral) String() string       { return integerLiteral.Token.Literal }
-func (integerLiteral *IntegerLiteral) expressionNode()      {}
+func (integerLiteral *IntegerLiteral) String() string {
+	if integerLiteral.Big != nil {
+		return integerLiteral.Big.String()
+	}
+	return strconv.FormatInt(integerLiteral.Value, 10)
+}
+func (integerLiteral *IntegerLiteral) expressionNode() {}
+
+// A Float is a type of Expression
+type FloatLiteral struct {
+	Token token.Token // The FLOAT token
+	Value float64
+}
+
+func (floatLiteral *FloatLiteral) TokenLiteral() string { return floatLiteral.Token.Literal }
+
+// A parsed float is printed as it was written, a float built by a tool with FormatFloat
+func (floatLiteral *FloatLiteral) String() string {
+	if floatLiteral.Token.Type == token.FLOAT {
+		return floatLiteral.Token.Literal
+	}
+	return FormatFloat(floatLiteral.Value)
+}
+func (floatLiteral *FloatLiteral) expressionNode() {}
+
+// A function to print a float the shortest way that reads back as the same float, e.g. 0.1, 2.0 or 1e+21
+// It always has a point or an exponent, so that it does not read back as an integer
+func FormatFloat(value float64) string {
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".e") {
+		text += ".0"
+	}
+	return text
+}
+
+// A Boolean is a type of Expression
+type Boolean struct {
+	Token token.Token // The TRUE or FALSE token
+	Value bool
+}
+
+func (boolean *Boolean) TokenLiteral() string { return boolean.Token.Literal }
+func (boolean *Boolean) String() string       { return boolean.Token.Literal }
+func (boolean *Boolean) expressionNode()      {}
+
+// A StringLiteral is a type of Expression
+type StringLiteral struct {
+	Token token.Token // The STRING token, its literal is the raw text between the quotes
+	Value string      // The text with its escapes decoded
+}
+
+func (stringLiteral *StringLiteral) TokenLiteral() string { return stringLiteral.Token.Literal }
+
+// A parsed string is printed as it was written, a string built by a tool is quoted
+func (stringLiteral *StringLiteral) String() string {
+	if stringLiteral.Token.Type == token.STRING {
+		return `"` + stringLiteral.Token.Literal + `"`
+	}
+	return strconv.Quote(stringLiteral.Value)
+}
+func (stringLiteral *StringLiteral) expressionNode() {}
 
 // A PrefixExpression is a type of Expression
 type PrefixExpression struct {
@@ -80,6 +162,152 @@ func (infixExpression *InfixExpression) String() string {
 }
 func (InfixExpression *InfixExpression) expressionNode() {}
 
+// An IndexExpression is a type of Expression
+type IndexExpression struct {
+	Token token.Token // The "[" token
+	Left  Expression  // The indexed Expression
+	Index Expression  // The index Expression
+}
+
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+	return out.String()
+}
+func (ie *IndexExpression) expressionNode() {}
+
+// An AssignExpression is a type of Expression
+type AssignExpression struct {
+	Token    token.Token // The assignment token: ASSIGN, PLUSASSIGN, MINUSASSIGN, ...
+	Target   Expression  // The assigned Expression: a Variable or an IndexExpression
+	Operator string      // The assignment operator: "=", "+=", "-=", ...
+	Value    Expression  // The assigned value
+}
+
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+	return out.String()
+}
+func (ae *AssignExpression) expressionNode() {}
+
+// An ArrayLiteral is a type of Expression
+type ArrayLiteral struct {
+	Token    token.Token // The "[" token
+	Elements []Expression
+	End      token.Token // The "]" token
+}
+
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) String() string {
+	elements := []string{}
+	for _, element := range al.Elements {
+		elements = append(elements, element.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+func (al *ArrayLiteral) expressionNode() {}
+
+// An IfExpression is a type of Expression
+type IfExpression struct {
+	Token       token.Token // The IF token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement // nil without an else
+}
+
+func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ie.TokenLiteral() + " (")
+	out.WriteString(ie.Condition.String())
+	out.WriteString(") ")
+	out.WriteString(ie.Consequence.String())
+
+	if ie.Alternative != nil {
+		out.WriteString(" else ")
+		out.WriteString(ie.Alternative.String())
+	}
+
+	return out.String()
+}
+func (ie *IfExpression) expressionNode() {}
+
+// A FunctionLiteral is a type of Expression
+type FunctionLiteral struct {
+	Token      token.Token // The FUNCTION token
+	Parameters []*Variable
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+
+	parameters := []string{}
+	for _, parameter := range fl.Parameters {
+		parameters = append(parameters, parameter.String())
+	}
+
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(parameters, ", "))
+	out.WriteString(") ")
+	out.WriteString(fl.Body.String())
+
+	return out.String()
+}
+func (fl *FunctionLiteral) expressionNode() {}
+
+// A CallExpression is a type of Expression
+type CallExpression struct {
+	Token     token.Token // The "(" token
+	Function  Expression  // The called Expression: a Variable or a FunctionLiteral
+	Arguments []Expression
+}
+
+func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+
+	arguments := []string{}
+	for _, argument := range ce.Arguments {
+		arguments = append(arguments, argument.String())
+	}
+
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(arguments, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+func (ce *CallExpression) expressionNode() {}
+
+// An ImportExpression is a type of Expression
+// Its value is the module the path refers to
+type ImportExpression struct {
+	Token token.Token    // The IMPORT token
+	Path  *StringLiteral // The path of the module, e.g. "lib/util"
+}
+
+func (ie *ImportExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *ImportExpression) String() string {
+	return ie.TokenLiteral() + " " + ie.Path.String()
+}
+func (ie *ImportExpression) expressionNode() {}
+
 // A Statment is a type of Node
 type Statement interface {
 	Node
@@ -111,6 +339,19 @@ func (ls *LetStatement) String() string {
 }
 func (ls *LetStatement) statementNode() {}
 
+// An ExportStatement is a type of Statement
+// It can only appear at the top level of a module
+type ExportStatement struct {
+	Token     token.Token // The EXPORT token
+	Statement *LetStatement
+}
+
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+func (es *ExportStatement) statementNode() {}
+
 // A ReturnStatement is a type of Statement
 type ReturnStatement struct {
 	Token       token.Token // The RETURN token
@@ -150,9 +391,170 @@ func (es *ExpressionStatement) String() string {
 	return out.String()
 }
 
+// A BlockStatement is a type of Statement
+type BlockStatement struct {
+	Token      token.Token // The "{" token
+	Statements []Statement
+	End        token.Token // The "}" token
+}
+
+func (bs *BlockStatement) statementNode()       {}
+func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("{ ")
+	for _, s := range bs.Statements {
+		out.WriteString(s.String())
+	}
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// A ThrowStatement is a type of Statement
+type ThrowStatement struct {
+	Token token.Token // The THROW token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// A TryStatement is a type of Statement
+// It must have a catch block, a finally block or both
+type TryStatement struct {
+	Token      token.Token     // The TRY token
+	Block      *BlockStatement // The guarded block
+	CatchParam *Variable       // The variable the caught value is bound to, nil without a catch
+	Catch      *BlockStatement // The catch block, nil without a catch
+	Finally    *BlockStatement // The finally block, nil without a finally
+}
+
+func (ts *TryStatement) statementNode()       {}
+func (ts *TryStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+	out.WriteString(ts.Block.String())
+	if ts.Catch != nil {
+		out.WriteString(" catch (" + ts.CatchParam.String() + ") ")
+		out.WriteString(ts.Catch.String())
+	}
+	if ts.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(ts.Finally.String())
+	}
+
+	return out.String()
+}
+
+// A WhileStatement is a type of Statement
+type WhileStatement struct {
+	Token     token.Token // The WHILE token
+	Label     *Variable   // The label of the loop, nil if unlabelled
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	if ws.Label != nil {
+		out.WriteString(ws.Label.String() + ": ")
+	}
+	out.WriteString(ws.TokenLiteral() + " (")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(") ")
+	out.WriteString(ws.Body.String())
+
+	return out.String()
+}
+
+// A ForStatement is a type of Statement
+type ForStatement struct {
+	Token    token.Token // The FOR token
+	Label    *Variable   // The label of the loop, nil if unlabelled
+	Variable *Variable   // The variable each element is bound to
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	if fs.Label != nil {
+		out.WriteString(fs.Label.String() + ": ")
+	}
+	out.WriteString(fs.TokenLiteral() + " (")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// A BreakStatement is a type of Statement
+type BreakStatement struct {
+	Token token.Token // The BREAK token
+	Label *Variable   // The label of the loop to break out of, nil for the innermost loop
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) String() string {
+	if bs.Label != nil {
+		return bs.TokenLiteral() + " " + bs.Label.String() + ";"
+	}
+	return bs.TokenLiteral() + ";"
+}
+
+// A ContinueStatement is a type of Statement
+type ContinueStatement struct {
+	Token token.Token // The CONTINUE token
+	Label *Variable   // The label of the loop to continue, nil for the innermost loop
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) String() string {
+	if cs.Label != nil {
+		return cs.TokenLiteral() + " " + cs.Label.String() + ";"
+	}
+	return cs.TokenLiteral() + ";"
+}
+
+// A Comment is not part of the statements of a program
+// It is kept so that tools like the formatter can print it back
+type Comment struct {
+	Token token.Token // The COMMENT token, its literal includes the leading "//"
+}
+
+func (comment *Comment) TokenLiteral() string { return comment.Token.Literal }
+func (comment *Comment) String() string       { return comment.Token.Literal }
+
 // A program is an array of Statement
 type Program struct {
 	Statements []Statement
+	Comments   []*Comment // The comments of the program, in source order
 }
 
 func (program *Program) TokenLiteral() string {
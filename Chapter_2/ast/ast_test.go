@@ -20,3 +20,23 @@ func TestSTring(t *testing.T) {
 		t.Errorf("program.String() wrong, got = %q", program.String())
 	}
 }
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{3.14, "3.14"},
+		{2, "2.0"},
+		{-0.5, "-0.5"},
+		{0.30000000000000004, "0.30000000000000004"},
+		{1e21, "1e+21"},
+		{1e-7, "1e-07"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatFloat(tt.value); got != tt.expected {
+			t.Errorf("FormatFloat(%v) wrong, expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ast
+
+// Dumps of the AST for teaching and debugging
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A function to return the label of a node: its type and its token literal
+// e.g. InfixExpression "+"
+func nodeLabel(node Node) string {
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+	if _, ok := node.(*Program); ok {
+		return kind
+	}
+	return kind + " " + strconv.Quote(node.TokenLiteral())
+}
+
+// A dotVisitor writes a node and the edge from its parent for every node it visits
+type dotVisitor struct {
+	out     *bytes.Buffer
+	nextID  int
+	parents []int // the ids of the nodes being visited, innermost last
+}
+
+func (dv *dotVisitor) Visit(node Node) Visitor {
+	// Leaving a node
+	if node == nil {
+		dv.parents = dv.parents[:len(dv.parents)-1]
+		return nil
+	}
+
+	id := dv.nextID
+	dv.nextID += 1
+	fmt.Fprintf(dv.out, "\tn%d [label=%s];\n", id, strconv.Quote(nodeLabel(node)))
+	if len(dv.parents) > 0 {
+		fmt.Fprintf(dv.out, "\tn%d -> n%d;\n", dv.parents[len(dv.parents)-1], id)
+	}
+	dv.parents = append(dv.parents, id)
+
+	return dv
+}
+
+// A function to render a node and its children as a Graphviz DOT graph
+// with one graph node per AST node, labelled with its type and token literal
+func DOT(node Node) string {
+	var out bytes.Buffer
+	out.WriteString("digraph AST {\n")
+	out.WriteString("\tnode [shape=box];\n")
+	Walk(&dotVisitor{out: &out}, node)
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// A sexpVisitor writes an opening parenthesis when it enters a node
+// and a closing one when it leaves it
+type sexpVisitor struct {
+	out   *bytes.Buffer
+	depth int
+}
+
+func (sv *sexpVisitor) Visit(node Node) Visitor {
+	// Leaving a node
+	if node == nil {
+		sv.out.WriteString(")")
+		sv.depth -= 1
+		return nil
+	}
+
+	if sv.depth > 0 {
+		sv.out.WriteString("\n" + strings.Repeat("  ", sv.depth))
+	}
+	sv.out.WriteString("(" + nodeLabel(node))
+	sv.depth += 1
+
+	return sv
+}
+
+// A function to render a node and its children as an indented S-expression
+// with one list per AST node, headed by its type and token literal
+func SExpression(node Node) string {
+	var out bytes.Buffer
+	Walk(&sexpVisitor{out: &out}, node)
+	out.WriteString("\n")
+	return out.String()
+}
@@ -0,0 +1,63 @@
+package ast
+
+import (
+	"Chapter_2/token"
+	"testing"
+)
+
+func newDumpProgram() *Program {
+	return &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token:    token.Token{Type: token.LET, Literal: "let"},
+				Variable: newVariable("x"),
+				Expression: &InfixExpression{
+					Token:      token.Token{Type: token.PLUS, Literal: "+"},
+					LeftValue:  newInteger(1),
+					Operator:   "+",
+					RightValue: newInteger(2),
+				},
+			},
+		},
+	}
+}
+
+func TestDOT(t *testing.T) {
+	expected := `digraph AST {
+	node [shape=box];
+	n0 [label="Program"];
+	n1 [label="LetStatement \"let\""];
+	n0 -> n1;
+	n2 [label="Variable \"x\""];
+	n1 -> n2;
+	n3 [label="InfixExpression \"+\""];
+	n1 -> n3;
+	n4 [label="IntegerLiteral \"1\""];
+	n3 -> n4;
+	n5 [label="IntegerLiteral \"2\""];
+	n3 -> n5;
+}
+`
+
+	if DOT(newDumpProgram()) != expected {
+		t.Errorf("DOT() is not\n%s\ngot =\n%s", expected, DOT(newDumpProgram()))
+	}
+}
+
+func TestSExpression(t *testing.T) {
+	expected := `(Program
+  (LetStatement "let"
+    (Variable "x")
+    (InfixExpression "+"
+      (IntegerLiteral "1")
+      (IntegerLiteral "2"))))
+`
+
+	if SExpression(newDumpProgram()) != expected {
+		t.Errorf("SExpression() is not\n%s\ngot =\n%s", expected, SExpression(newDumpProgram()))
+	}
+
+	if SExpression(newVariable("y")) != "(Variable \"y\")\n" {
+		t.Errorf("SExpression() of a leaf is wrong. got = %q", SExpression(newVariable("y")))
+	}
+}
@@ -0,0 +1,654 @@
+package ast
+
+// JSON serialization of the AST
+//
+// Every node is an object tagged with its "kind", e.g.
+//
+//	{"kind":"InfixExpression","token":{...},"left":{...},"operator":"+","right":{...}}
+//
+// Tokens keep their type, literal and position so that the round trip is lossless
+// The comments of a Program are kept as a list of tokens
+
+import (
+	"Chapter_2/token"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// The JSON representation of a token
+type jsonToken struct {
+	Type    string `json:"type"`
+	Literal string `json:"literal"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+func encodeToken(tok token.Token) jsonToken {
+	return jsonToken{Type: string(tok.Type), Literal: tok.Literal, Line: tok.Line, Column: tok.Column}
+}
+
+func (jt jsonToken) token() token.Token {
+	return token.Token{Type: token.TokenType(jt.Type), Literal: jt.Literal, Line: jt.Line, Column: jt.Column}
+}
+
+// A function to serialize a node, and all of its children, to JSON
+func MarshalJSON(node Node) ([]byte, error) {
+	encoded, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// A function to turn a node into a tagged map that encoding/json can serialize
+// A missing node is encoded as null
+func encodeNode(node Node) (map[string]any, error) {
+	if node == nil {
+		return nil, nil
+	}
+
+	var fields map[string]any
+	var err error
+	// The children of a node, in the order they are encoded
+	children := map[string]Node{}
+
+	switch n := node.(type) {
+	// Expressions
+	case *Variable:
+		if n == nil {
+			return nil, nil
+		}
+		fields = map[string]any{"kind": "Variable", "token": encodeToken(n.Token), "literal": n.Literal}
+
+	case *IntegerLiteral:
+		fields = map[string]any{"kind": "IntegerLiteral", "token": encodeToken(n.Token), "value": n.String()}
+
+	case *FloatLiteral:
+		fields = map[string]any{"kind": "FloatLiteral", "token": encodeToken(n.Token), "value": n.Value}
+
+	case *Boolean:
+		fields = map[string]any{"kind": "Boolean", "token": encodeToken(n.Token), "value": n.Value}
+
+	case *StringLiteral:
+		if n == nil {
+			return nil, nil
+		}
+		fields = map[string]any{"kind": "StringLiteral", "token": encodeToken(n.Token), "value": n.Value}
+
+	case *PrefixExpression:
+		fields = map[string]any{"kind": "PrefixExpression", "token": encodeToken(n.Token), "operator": n.Operator}
+		children["right"] = n.Right
+
+	case *InfixExpression:
+		fields = map[string]any{"kind": "InfixExpression", "token": encodeToken(n.Token), "operator": n.Operator}
+		children["left"] = n.LeftValue
+		children["right"] = n.RightValue
+
+	case *IndexExpression:
+		fields = map[string]any{"kind": "IndexExpression", "token": encodeToken(n.Token)}
+		children["left"] = n.Left
+		children["index"] = n.Index
+
+	case *AssignExpression:
+		fields = map[string]any{"kind": "AssignExpression", "token": encodeToken(n.Token), "operator": n.Operator}
+		children["target"] = n.Target
+		children["value"] = n.Value
+
+	case *ArrayLiteral:
+		fields = map[string]any{"kind": "ArrayLiteral", "token": encodeToken(n.Token), "end": encodeToken(n.End)}
+		elements := []Node{}
+		for _, element := range n.Elements {
+			elements = append(elements, element)
+		}
+		fields["elements"], err = encodeNodes(elements)
+
+	case *IfExpression:
+		fields = map[string]any{"kind": "IfExpression", "token": encodeToken(n.Token)}
+		children["condition"] = n.Condition
+		children["consequence"] = blockNode(n.Consequence)
+		children["alternative"] = blockNode(n.Alternative)
+
+	case *FunctionLiteral:
+		fields = map[string]any{"kind": "FunctionLiteral", "token": encodeToken(n.Token)}
+		parameters := []Node{}
+		for _, parameter := range n.Parameters {
+			parameters = append(parameters, parameter)
+		}
+		fields["parameters"], err = encodeNodes(parameters)
+		children["body"] = blockNode(n.Body)
+
+	case *CallExpression:
+		fields = map[string]any{"kind": "CallExpression", "token": encodeToken(n.Token)}
+		arguments := []Node{}
+		for _, argument := range n.Arguments {
+			arguments = append(arguments, argument)
+		}
+		fields["arguments"], err = encodeNodes(arguments)
+		children["function"] = n.Function
+
+	case *ImportExpression:
+		fields = map[string]any{"kind": "ImportExpression", "token": encodeToken(n.Token)}
+		children["path"] = n.Path
+
+	// Statements
+	case *LetStatement:
+		if n == nil {
+			return nil, nil
+		}
+		fields = map[string]any{"kind": "LetStatement", "token": encodeToken(n.Token)}
+		children["variable"] = variableNode(n.Variable)
+		children["expression"] = n.Expression
+
+	case *ExportStatement:
+		fields = map[string]any{"kind": "ExportStatement", "token": encodeToken(n.Token)}
+		children["statement"] = n.Statement
+
+	case *ReturnStatement:
+		fields = map[string]any{"kind": "ReturnStatement", "token": encodeToken(n.Token)}
+		children["returnValue"] = n.ReturnValue
+
+	case *ExpressionStatement:
+		fields = map[string]any{"kind": "ExpressionStatement", "token": encodeToken(n.Token)}
+		children["expression"] = n.Expression
+
+	case *BlockStatement:
+		if n == nil {
+			return nil, nil
+		}
+		fields = map[string]any{"kind": "BlockStatement", "token": encodeToken(n.Token), "end": encodeToken(n.End)}
+		fields["statements"], err = encodeStatements(n.Statements)
+
+	case *ThrowStatement:
+		fields = map[string]any{"kind": "ThrowStatement", "token": encodeToken(n.Token)}
+		children["value"] = n.Value
+
+	case *TryStatement:
+		fields = map[string]any{"kind": "TryStatement", "token": encodeToken(n.Token)}
+		children["block"] = blockNode(n.Block)
+		children["catchParam"] = variableNode(n.CatchParam)
+		children["catch"] = blockNode(n.Catch)
+		children["finally"] = blockNode(n.Finally)
+
+	case *WhileStatement:
+		fields = map[string]any{"kind": "WhileStatement", "token": encodeToken(n.Token)}
+		children["label"] = variableNode(n.Label)
+		children["condition"] = n.Condition
+		children["body"] = blockNode(n.Body)
+
+	case *ForStatement:
+		fields = map[string]any{"kind": "ForStatement", "token": encodeToken(n.Token)}
+		children["label"] = variableNode(n.Label)
+		children["variable"] = variableNode(n.Variable)
+		children["iterable"] = n.Iterable
+		children["body"] = blockNode(n.Body)
+
+	case *BreakStatement:
+		fields = map[string]any{"kind": "BreakStatement", "token": encodeToken(n.Token)}
+		children["label"] = variableNode(n.Label)
+
+	case *ContinueStatement:
+		fields = map[string]any{"kind": "ContinueStatement", "token": encodeToken(n.Token)}
+		children["label"] = variableNode(n.Label)
+
+	// Program
+	case *Program:
+		fields = map[string]any{"kind": "Program"}
+		fields["statements"], err = encodeStatements(n.Statements)
+		if len(n.Comments) > 0 {
+			comments := []jsonToken{}
+			for _, comment := range n.Comments {
+				comments = append(comments, encodeToken(comment.Token))
+			}
+			fields["comments"] = comments
+		}
+
+	default:
+		return nil, fmt.Errorf("ast: cannot marshal node of type %T", node)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for name, child := range children {
+		if fields[name], err = encodeNode(child); err != nil {
+			return nil, err
+		}
+	}
+
+	return fields, nil
+}
+
+// A function to encode a list of statements in order
+func encodeStatements(statements []Statement) ([]map[string]any, error) {
+	nodes := []Node{}
+	for _, s := range statements {
+		nodes = append(nodes, s)
+	}
+	return encodeNodes(nodes)
+}
+
+// A function to encode a list of nodes in order
+func encodeNodes(nodes []Node) ([]map[string]any, error) {
+	encoded := []map[string]any{}
+	for _, node := range nodes {
+		e, err := encodeNode(node)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, e)
+	}
+	return encoded, nil
+}
+
+// Functions to keep a missing *Variable or *BlockStatement as a nil Node
+// rather than a non-nil Node holding a nil pointer
+func variableNode(variable *Variable) Node {
+	if variable == nil {
+		return nil
+	}
+	return variable
+}
+
+func blockNode(block *BlockStatement) Node {
+	if block == nil {
+		return nil
+	}
+	return block
+}
+
+// A function to deserialize a node, and all of its children, from the JSON produced by MarshalJSON
+func UnmarshalJSON(data []byte) (Node, error) {
+	return decodeNode(data)
+}
+
+// The fields of an encoded node, decoded lazily
+type jsonFields map[string]json.RawMessage
+
+// A function to decode the token of a node
+func (f jsonFields) token() (token.Token, error) {
+	var jt jsonToken
+	if err := json.Unmarshal(f["token"], &jt); err != nil {
+		return token.Token{}, fmt.Errorf("ast: invalid token: %w", err)
+	}
+	return jt.token(), nil
+}
+
+// A function to decode the closing token of a block or an array literal
+// It is the zero token if it is missing
+func (f jsonFields) end() (token.Token, error) {
+	if f["end"] == nil {
+		return token.Token{}, nil
+	}
+	var jt jsonToken
+	if err := json.Unmarshal(f["end"], &jt); err != nil {
+		return token.Token{}, fmt.Errorf("ast: invalid end: %w", err)
+	}
+	return jt.token(), nil
+}
+
+// A function to decode a string field of a node
+func (f jsonFields) string(name string) (string, error) {
+	var s string
+	if err := json.Unmarshal(f[name], &s); err != nil {
+		return "", fmt.Errorf("ast: invalid %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// A function to decode a boolean field of a node
+func (f jsonFields) bool(name string) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(f[name], &b); err != nil {
+		return false, fmt.Errorf("ast: invalid %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// A function to decode a float field of a node
+func (f jsonFields) float(name string) (float64, error) {
+	var x float64
+	if err := json.Unmarshal(f[name], &x); err != nil {
+		return 0, fmt.Errorf("ast: invalid %s: %w", name, err)
+	}
+	return x, nil
+}
+
+// A function to decode an expression field of a node, nil if it is null or missing
+func (f jsonFields) expression(name string) (Expression, error) {
+	node, err := decodeNode(f[name])
+	if err != nil || node == nil {
+		return nil, err
+	}
+	expression, ok := node.(Expression)
+	if !ok {
+		return nil, fmt.Errorf("ast: %s is a %T, not an Expression", name, node)
+	}
+	return expression, nil
+}
+
+// A function to decode a Variable field of a node, nil if it is null or missing
+func (f jsonFields) variable(name string) (*Variable, error) {
+	node, err := decodeNode(f[name])
+	if err != nil || node == nil {
+		return nil, err
+	}
+	variable, ok := node.(*Variable)
+	if !ok {
+		return nil, fmt.Errorf("ast: %s is a %T, not a Variable", name, node)
+	}
+	return variable, nil
+}
+
+// A function to decode a BlockStatement field of a node, nil if it is null or missing
+func (f jsonFields) block(name string) (*BlockStatement, error) {
+	node, err := decodeNode(f[name])
+	if err != nil || node == nil {
+		return nil, err
+	}
+	block, ok := node.(*BlockStatement)
+	if !ok {
+		return nil, fmt.Errorf("ast: %s is a %T, not a BlockStatement", name, node)
+	}
+	return block, nil
+}
+
+// A function to decode a StringLiteral field of a node, nil if it is null or missing
+func (f jsonFields) stringLiteral(name string) (*StringLiteral, error) {
+	node, err := decodeNode(f[name])
+	if err != nil || node == nil {
+		return nil, err
+	}
+	literal, ok := node.(*StringLiteral)
+	if !ok {
+		return nil, fmt.Errorf("ast: %s is a %T, not a StringLiteral", name, node)
+	}
+	return literal, nil
+}
+
+// A function to decode a LetStatement field of a node, nil if it is null or missing
+func (f jsonFields) letStatement(name string) (*LetStatement, error) {
+	node, err := decodeNode(f[name])
+	if err != nil || node == nil {
+		return nil, err
+	}
+	statement, ok := node.(*LetStatement)
+	if !ok {
+		return nil, fmt.Errorf("ast: %s is a %T, not a LetStatement", name, node)
+	}
+	return statement, nil
+}
+
+// A function to decode the list of statements of a node
+func (f jsonFields) statements() ([]Statement, error) {
+	nodes, err := f.nodes("statements")
+	if err != nil {
+		return nil, err
+	}
+
+	statements := []Statement{}
+	for _, node := range nodes {
+		statement, ok := node.(Statement)
+		if !ok {
+			return nil, fmt.Errorf("ast: %T is not a Statement", node)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// A function to decode a list of expressions of a node
+func (f jsonFields) expressions(name string) ([]Expression, error) {
+	nodes, err := f.nodes(name)
+	if err != nil {
+		return nil, err
+	}
+
+	expressions := []Expression{}
+	for _, node := range nodes {
+		expression, ok := node.(Expression)
+		if !ok {
+			return nil, fmt.Errorf("ast: %T is not an Expression", node)
+		}
+		expressions = append(expressions, expression)
+	}
+	return expressions, nil
+}
+
+// A function to decode a list of variables of a node
+func (f jsonFields) variables(name string) ([]*Variable, error) {
+	nodes, err := f.nodes(name)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := []*Variable{}
+	for _, node := range nodes {
+		variable, ok := node.(*Variable)
+		if !ok {
+			return nil, fmt.Errorf("ast: %T is not a Variable", node)
+		}
+		variables = append(variables, variable)
+	}
+	return variables, nil
+}
+
+// A function to decode a list of nodes of a node
+func (f jsonFields) nodes(name string) ([]Node, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(f[name], &raws); err != nil {
+		return nil, fmt.Errorf("ast: invalid %s: %w", name, err)
+	}
+
+	nodes := []Node{}
+	for _, raw := range raws {
+		node, err := decodeNode(raw)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// A function to decode one encoded node
+// A null or missing node is decoded as nil
+func decodeNode(data json.RawMessage) (Node, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	var f jsonFields
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("ast: invalid node: %w", err)
+	}
+
+	kind, err := f.string("kind")
+	if err != nil {
+		return nil, err
+	}
+
+	// The Program is the only node without a token
+	if kind == "Program" {
+		statements, err := f.statements()
+		if err != nil {
+			return nil, err
+		}
+		program := &Program{Statements: statements}
+
+		var comments []jsonToken
+		if f["comments"] != nil {
+			if err := json.Unmarshal(f["comments"], &comments); err != nil {
+				return nil, fmt.Errorf("ast: invalid comments: %w", err)
+			}
+		}
+		for _, comment := range comments {
+			program.Comments = append(program.Comments, &Comment{Token: comment.token()})
+		}
+
+		return program, nil
+	}
+
+	tok, err := f.token()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the errors of the field decoders so each case stays a single expression
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	expression := func(name string) Expression {
+		e, err := f.expression(name)
+		check(err)
+		return e
+	}
+	variable := func(name string) *Variable {
+		v, err := f.variable(name)
+		check(err)
+		return v
+	}
+	block := func(name string) *BlockStatement {
+		b, err := f.block(name)
+		check(err)
+		return b
+	}
+	str := func(name string) string {
+		s, err := f.string(name)
+		check(err)
+		return s
+	}
+
+	var node Node
+	switch kind {
+	// Expressions
+	case "Variable":
+		node = &Variable{Token: tok, Literal: str("literal")}
+
+	case "IntegerLiteral":
+		node, err = decodeIntegerLiteral(tok, str("value"))
+		check(err)
+
+	case "FloatLiteral":
+		value, err := f.float("value")
+		check(err)
+		node = &FloatLiteral{Token: tok, Value: value}
+
+	case "Boolean":
+		value, err := f.bool("value")
+		check(err)
+		node = &Boolean{Token: tok, Value: value}
+
+	case "StringLiteral":
+		node = &StringLiteral{Token: tok, Value: str("value")}
+
+	case "PrefixExpression":
+		node = &PrefixExpression{Token: tok, Operator: str("operator"), Right: expression("right")}
+
+	case "InfixExpression":
+		node = &InfixExpression{Token: tok, LeftValue: expression("left"), Operator: str("operator"), RightValue: expression("right")}
+
+	case "IndexExpression":
+		node = &IndexExpression{Token: tok, Left: expression("left"), Index: expression("index")}
+
+	case "AssignExpression":
+		node = &AssignExpression{Token: tok, Target: expression("target"), Operator: str("operator"), Value: expression("value")}
+
+	case "ArrayLiteral":
+		elements, err := f.expressions("elements")
+		check(err)
+		end, err := f.end()
+		check(err)
+		node = &ArrayLiteral{Token: tok, Elements: elements, End: end}
+
+	case "IfExpression":
+		node = &IfExpression{Token: tok, Condition: expression("condition"), Consequence: block("consequence"), Alternative: block("alternative")}
+
+	case "FunctionLiteral":
+		parameters, err := f.variables("parameters")
+		check(err)
+		node = &FunctionLiteral{Token: tok, Parameters: parameters, Body: block("body")}
+
+	case "CallExpression":
+		arguments, err := f.expressions("arguments")
+		check(err)
+		node = &CallExpression{Token: tok, Function: expression("function"), Arguments: arguments}
+
+	case "ImportExpression":
+		path, err := f.stringLiteral("path")
+		check(err)
+		node = &ImportExpression{Token: tok, Path: path}
+
+	// Statements
+	case "LetStatement":
+		node = &LetStatement{Token: tok, Variable: variable("variable"), Expression: expression("expression")}
+
+	case "ExportStatement":
+		statement, err := f.letStatement("statement")
+		check(err)
+		node = &ExportStatement{Token: tok, Statement: statement}
+
+	case "ReturnStatement":
+		node = &ReturnStatement{Token: tok, ReturnValue: expression("returnValue")}
+
+	case "ExpressionStatement":
+		node = &ExpressionStatement{Token: tok, Expression: expression("expression")}
+
+	case "BlockStatement":
+		statements, err := f.statements()
+		check(err)
+		end, err := f.end()
+		check(err)
+		node = &BlockStatement{Token: tok, Statements: statements, End: end}
+
+	case "ThrowStatement":
+		node = &ThrowStatement{Token: tok, Value: expression("value")}
+
+	case "TryStatement":
+		node = &TryStatement{Token: tok, Block: block("block"), CatchParam: variable("catchParam"), Catch: block("catch"), Finally: block("finally")}
+
+	case "WhileStatement":
+		node = &WhileStatement{Token: tok, Label: variable("label"), Condition: expression("condition"), Body: block("body")}
+
+	case "ForStatement":
+		node = &ForStatement{Token: tok, Label: variable("label"), Variable: variable("variable"), Iterable: expression("iterable"), Body: block("body")}
+
+	case "BreakStatement":
+		node = &BreakStatement{Token: tok, Label: variable("label")}
+
+	case "ContinueStatement":
+		node = &ContinueStatement{Token: tok, Label: variable("label")}
+
+	default:
+		return nil, fmt.Errorf("ast: unknown node kind %q", kind)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	return node, nil
+}
+
+// A function to rebuild an IntegerLiteral from its decimal value
+func decodeIntegerLiteral(tok token.Token, value string) (*IntegerLiteral, error) {
+	literal := &IntegerLiteral{Token: tok}
+
+	if small, err := strconv.ParseInt(value, 10, 64); err == nil {
+		literal.Value = small
+		return literal, nil
+	}
+
+	bigValue, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("ast: invalid integer value %q", value)
+	}
+	literal.Big = bigValue
+
+	return literal, nil
+}
@@ -0,0 +1,139 @@
+package ast
+
+import (
+	"Chapter_2/token"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	program := newWalkProgram()
+	// A position and a big integer should survive the round trip too
+	program.Statements[0].(*LetStatement).Token = token.Token{Type: token.LET, Literal: "let", Line: 2, Column: 3}
+	bigValue, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	program.Statements = append(program.Statements, newExpressionStatement(&IntegerLiteral{
+		Token: token.Token{Type: token.INT, Literal: "123456789012345678901234567890"},
+		Big:   bigValue,
+	}))
+	program.Statements = append(program.Statements, newExpressionStatement(&FloatLiteral{
+		Token: token.Token{Type: token.FLOAT, Literal: "6.02e23"},
+		Value: 6.02e23,
+	}))
+	// fn(a, b) { a; }(1, [x]);
+	program.Statements = append(program.Statements, newExpressionStatement(&CallExpression{
+		Token: token.Token{Type: token.RLBRACKET, Literal: "("},
+		Function: &FunctionLiteral{
+			Token:      token.Token{Type: token.FUNCTION, Literal: "fn"},
+			Parameters: []*Variable{newVariable("a"), newVariable("b")},
+			Body:       newBlock(newExpressionStatement(newVariable("a"))),
+		},
+		Arguments: []Expression{newInteger(1), &ArrayLiteral{
+			Token:    token.Token{Type: token.SLBRACKET, Literal: "[", Line: 4, Column: 1},
+			Elements: []Expression{newVariable("x")},
+			End:      token.Token{Type: token.SRBRACKET, Literal: "]", Line: 5, Column: 1},
+		}},
+	}))
+	// if (true) { x; } else { 1; };
+	program.Statements = append(program.Statements, newExpressionStatement(&IfExpression{
+		Token:       token.Token{Type: token.IF, Literal: "if"},
+		Condition:   &Boolean{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true},
+		Consequence: newBlock(newExpressionStatement(newVariable("x"))),
+		Alternative: newBlock(newExpressionStatement(newInteger(1))),
+	}))
+	// export let m = import "lib/util";
+	program.Statements = append(program.Statements, &ExportStatement{
+		Token: token.Token{Type: token.EXPORT, Literal: "export"},
+		Statement: &LetStatement{
+			Token:    token.Token{Type: token.LET, Literal: "let"},
+			Variable: newVariable("m"),
+			Expression: &ImportExpression{
+				Token: token.Token{Type: token.IMPORT, Literal: "import"},
+				Path:  &StringLiteral{Token: token.Token{Type: token.STRING, Literal: "lib/util"}, Value: "lib/util"},
+			},
+		},
+	})
+	program.Comments = []*Comment{{Token: token.Token{Type: token.COMMENT, Literal: "// note", Line: 1, Column: 1}}}
+
+	data, err := MarshalJSON(program)
+	if err != nil {
+		t.Fatalf("MarshalJSON() returned an error: %s", err)
+	}
+
+	node, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() returned an error: %s", err)
+	}
+
+	decoded, ok := node.(*Program)
+	if !ok {
+		t.Fatalf("UnmarshalJSON() did not return *Program. got = %T", node)
+	}
+
+	if decoded.String() != program.String() {
+		t.Errorf("decoded.String() is not %q. got = %q", program.String(), decoded.String())
+	}
+
+	if !reflect.DeepEqual(decoded, program) {
+		t.Errorf("decoded program is not equal to the original program")
+	}
+
+	let := decoded.Statements[0].(*LetStatement)
+	if let.Token.Position() != "2:3" {
+		t.Errorf("let.Token.Position() is not 2:3. got = %s", let.Token.Position())
+	}
+}
+
+func TestJSONTaggedRepresentation(t *testing.T) {
+	infix := &InfixExpression{
+		Token:      token.Token{Type: token.PLUS, Literal: "+", Line: 1, Column: 3},
+		LeftValue:  newInteger(1),
+		Operator:   "+",
+		RightValue: newVariable("x"),
+	}
+
+	data, err := MarshalJSON(infix)
+	if err != nil {
+		t.Fatalf("MarshalJSON() returned an error: %s", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("MarshalJSON() did not produce valid JSON: %s", err)
+	}
+
+	if decoded["kind"] != "InfixExpression" {
+		t.Errorf("kind is not %q. got = %v", "InfixExpression", decoded["kind"])
+	}
+
+	if decoded["operator"] != "+" {
+		t.Errorf("operator is not %q. got = %v", "+", decoded["operator"])
+	}
+
+	tok := decoded["token"].(map[string]any)
+	if tok["line"] != 1.0 || tok["column"] != 3.0 {
+		t.Errorf("token position is not 1:3. got = %v:%v", tok["line"], tok["column"])
+	}
+
+	left := decoded["left"].(map[string]any)
+	if left["kind"] != "IntegerLiteral" || left["value"] != "1" {
+		t.Errorf("left is not the IntegerLiteral 1. got = %v", left)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	tests := []string{
+		`{"kind":"Unknown"}`,
+		`{"kind":"Program","statements":[{"kind":"Variable","token":{},"literal":"x"}]}`,
+		`{"kind":"IntegerLiteral","token":{},"value":"abc"}`,
+		`{"kind":"LetStatement","token":{},"variable":{"kind":"IntegerLiteral","token":{},"value":"1"}}`,
+		`[1, 2]`,
+	}
+
+	for _, input := range tests {
+		if _, err := UnmarshalJSON([]byte(input)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) did not return an error", input)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package ast
+
+// Rewriting of the AST
+
+// A ModifierFunc takes a node and returns the node that replaces it
+// Returning the node unchanged keeps it in the tree
+type ModifierFunc func(Node) Node
+
+// A function to rewrite an AST bottom-up
+// The children of a node are modified first, in source order,
+// then the node itself is passed to the modifier
+//
+// Nodes are updated in place, so nodes that the modifier returns unchanged keep their tokens and positions
+// A replacement that does not fit the slot of the original node (e.g. a Statement in place of an Expression)
+// is ignored and the original node is kept
+// A statement inside a Program or a BlockStatement is removed if the modifier returns nil for it
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+	// Expressions
+	case *PrefixExpression:
+		node.Right = modifyExpression(node.Right, modifier)
+
+	case *InfixExpression:
+		node.LeftValue = modifyExpression(node.LeftValue, modifier)
+		node.RightValue = modifyExpression(node.RightValue, modifier)
+
+	case *IndexExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Index = modifyExpression(node.Index, modifier)
+
+	case *AssignExpression:
+		node.Target = modifyExpression(node.Target, modifier)
+		node.Value = modifyExpression(node.Value, modifier)
+
+	case *ArrayLiteral:
+		for i, element := range node.Elements {
+			node.Elements[i] = modifyExpression(element, modifier)
+		}
+
+	case *IfExpression:
+		node.Condition = modifyExpression(node.Condition, modifier)
+		node.Consequence = modifyBlock(node.Consequence, modifier)
+		node.Alternative = modifyBlock(node.Alternative, modifier)
+
+	case *FunctionLiteral:
+		for i, parameter := range node.Parameters {
+			node.Parameters[i] = modifyVariable(parameter, modifier)
+		}
+		node.Body = modifyBlock(node.Body, modifier)
+
+	case *CallExpression:
+		node.Function = modifyExpression(node.Function, modifier)
+		for i, argument := range node.Arguments {
+			node.Arguments[i] = modifyExpression(argument, modifier)
+		}
+
+	case *ImportExpression:
+		if node.Path != nil {
+			if modified, ok := Modify(node.Path, modifier).(*StringLiteral); ok && modified != nil {
+				node.Path = modified
+			}
+		}
+
+	// Statements
+	case *LetStatement:
+		node.Variable = modifyVariable(node.Variable, modifier)
+		node.Expression = modifyExpression(node.Expression, modifier)
+
+	case *ExportStatement:
+		if node.Statement != nil {
+			if modified, ok := Modify(node.Statement, modifier).(*LetStatement); ok && modified != nil {
+				node.Statement = modified
+			}
+		}
+
+	case *ReturnStatement:
+		node.ReturnValue = modifyExpression(node.ReturnValue, modifier)
+
+	case *ExpressionStatement:
+		node.Expression = modifyExpression(node.Expression, modifier)
+
+	case *BlockStatement:
+		node.Statements = modifyStatements(node.Statements, modifier)
+
+	case *ThrowStatement:
+		node.Value = modifyExpression(node.Value, modifier)
+
+	case *TryStatement:
+		node.Block = modifyBlock(node.Block, modifier)
+		node.CatchParam = modifyVariable(node.CatchParam, modifier)
+		node.Catch = modifyBlock(node.Catch, modifier)
+		node.Finally = modifyBlock(node.Finally, modifier)
+
+	case *WhileStatement:
+		node.Label = modifyVariable(node.Label, modifier)
+		node.Condition = modifyExpression(node.Condition, modifier)
+		node.Body = modifyBlock(node.Body, modifier)
+
+	case *ForStatement:
+		node.Label = modifyVariable(node.Label, modifier)
+		node.Variable = modifyVariable(node.Variable, modifier)
+		node.Iterable = modifyExpression(node.Iterable, modifier)
+		node.Body = modifyBlock(node.Body, modifier)
+
+	case *BreakStatement:
+		node.Label = modifyVariable(node.Label, modifier)
+
+	case *ContinueStatement:
+		node.Label = modifyVariable(node.Label, modifier)
+
+	// Program
+	case *Program:
+		node.Statements = modifyStatements(node.Statements, modifier)
+	}
+
+	return modifier(node)
+}
+
+// A function to modify an expression that may be missing
+func modifyExpression(expression Expression, modifier ModifierFunc) Expression {
+	if expression == nil {
+		return nil
+	}
+	if modified, ok := Modify(expression, modifier).(Expression); ok {
+		return modified
+	}
+	return expression
+}
+
+// A function to modify a variable that may be missing
+func modifyVariable(variable *Variable, modifier ModifierFunc) *Variable {
+	if variable == nil {
+		return nil
+	}
+	if modified, ok := Modify(variable, modifier).(*Variable); ok && modified != nil {
+		return modified
+	}
+	return variable
+}
+
+// A function to modify a block that may be missing
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	if modified, ok := Modify(block, modifier).(*BlockStatement); ok && modified != nil {
+		return modified
+	}
+	return block
+}
+
+// A function to modify a list of statements in order
+// and drop the ones the modifier removed
+func modifyStatements(statements []Statement, modifier ModifierFunc) []Statement {
+	modified := []Statement{}
+	for _, s := range statements {
+		result := Modify(s, modifier)
+		if result == nil {
+			continue
+		}
+		if statement, ok := result.(Statement); ok {
+			modified = append(modified, statement)
+		} else {
+			modified = append(modified, s)
+		}
+	}
+	return modified
+}
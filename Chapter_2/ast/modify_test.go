@@ -0,0 +1,182 @@
+package ast
+
+import (
+	"Chapter_2/token"
+	"reflect"
+	"testing"
+)
+
+// An ExpressionStatement whose token does not depend on its expression
+func newStatement(expression Expression) *ExpressionStatement {
+	return &ExpressionStatement{Expression: expression}
+}
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return newInteger(1) }
+	two := func() Expression { return newInteger(2) }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+		return newInteger(2)
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{
+			&Program{Statements: []Statement{newStatement(one())}},
+			&Program{Statements: []Statement{newStatement(two())}},
+		},
+		{
+			&InfixExpression{LeftValue: one(), Operator: "+", RightValue: two()},
+			&InfixExpression{LeftValue: two(), Operator: "+", RightValue: two()},
+		},
+		{
+			&InfixExpression{LeftValue: two(), Operator: "+", RightValue: one()},
+			&InfixExpression{LeftValue: two(), Operator: "+", RightValue: two()},
+		},
+		{
+			&PrefixExpression{Operator: "-", Right: one()},
+			&PrefixExpression{Operator: "-", Right: two()},
+		},
+		{
+			&IndexExpression{Left: one(), Index: one()},
+			&IndexExpression{Left: two(), Index: two()},
+		},
+		{
+			&AssignExpression{Target: newVariable("x"), Operator: "=", Value: one()},
+			&AssignExpression{Target: newVariable("x"), Operator: "=", Value: two()},
+		},
+		{
+			&LetStatement{Variable: newVariable("x"), Expression: one()},
+			&LetStatement{Variable: newVariable("x"), Expression: two()},
+		},
+		{
+			&ReturnStatement{ReturnValue: one()},
+			&ReturnStatement{ReturnValue: two()},
+		},
+		{
+			&ThrowStatement{Value: one()},
+			&ThrowStatement{Value: two()},
+		},
+		{
+			newBlock(newStatement(one())),
+			newBlock(newStatement(two())),
+		},
+		{
+			&TryStatement{
+				Block:      newBlock(newStatement(one())),
+				CatchParam: newVariable("e"),
+				Catch:      newBlock(newStatement(one())),
+				Finally:    newBlock(newStatement(one())),
+			},
+			&TryStatement{
+				Block:      newBlock(newStatement(two())),
+				CatchParam: newVariable("e"),
+				Catch:      newBlock(newStatement(two())),
+				Finally:    newBlock(newStatement(two())),
+			},
+		},
+		{
+			&WhileStatement{Condition: one(), Body: newBlock(newStatement(one()))},
+			&WhileStatement{Condition: two(), Body: newBlock(newStatement(two()))},
+		},
+		{
+			&ForStatement{Variable: newVariable("x"), Iterable: one(), Body: newBlock(newStatement(one()))},
+			&ForStatement{Variable: newVariable("x"), Iterable: two(), Body: newBlock(newStatement(two()))},
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+
+		if !reflect.DeepEqual(modified, tt.expected) {
+			t.Errorf("not equal. got = %#v, want = %#v", modified, tt.expected)
+		}
+	}
+}
+
+func TestModifyBottomUp(t *testing.T) {
+	// -(1 + 2) with the children modified before their parent
+	input := &PrefixExpression{
+		Operator: "-",
+		Right:    &InfixExpression{LeftValue: newInteger(1), Operator: "+", RightValue: newInteger(2)},
+	}
+
+	order := []string{}
+	Modify(input, func(node Node) Node {
+		order = append(order, node.String())
+		return node
+	})
+
+	expected := []string{"1", "2", "(1 + 2)", "(-(1 + 2))"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("wrong modify order. got = %v, want = %v", order, expected)
+	}
+}
+
+func TestModifyReplacesAndRemovesStatements(t *testing.T) {
+	program := newWalkProgram()
+
+	modified := Modify(program, func(node Node) Node {
+		switch node := node.(type) {
+		// Drop every throw statement
+		case *ThrowStatement:
+			return nil
+		// Turn every return statement into an expression statement
+		case *ReturnStatement:
+			return newExpressionStatement(node.ReturnValue)
+		}
+		return node
+	}).(*Program)
+
+	if len(modified.Statements) != 7 {
+		t.Fatalf("modified.Statements does not contain 7 statements. got = %d", len(modified.Statements))
+	}
+
+	if _, ok := modified.Statements[1].(*ExpressionStatement); !ok {
+		t.Errorf("modified.Statements[1] is not *ExpressionStatement. got = %T", modified.Statements[1])
+	}
+
+	if _, ok := modified.Statements[4].(*TryStatement); !ok {
+		t.Errorf("modified.Statements[4] is not *TryStatement. got = %T", modified.Statements[4])
+	}
+}
+
+func TestModifyKeepsPositions(t *testing.T) {
+	variable := &Variable{Token: token.Token{Type: token.VARIABLE, Literal: "x", Line: 3, Column: 7}, Literal: "x"}
+	statement := &ExpressionStatement{
+		Token: token.Token{Type: token.VARIABLE, Literal: "x", Line: 3, Column: 7},
+		Expression: &InfixExpression{
+			Token:      token.Token{Type: token.PLUS, Literal: "+", Line: 3, Column: 9},
+			LeftValue:  variable,
+			Operator:   "+",
+			RightValue: newInteger(1),
+		},
+	}
+
+	Modify(statement, func(node Node) Node {
+		if _, ok := node.(*IntegerLiteral); ok {
+			return newInteger(5)
+		}
+		return node
+	})
+
+	infix := statement.Expression.(*InfixExpression)
+	if infix.LeftValue != variable || variable.Token.Line != 3 || variable.Token.Column != 7 {
+		t.Errorf("untouched variable lost its position. got = %s", variable.Token.Position())
+	}
+
+	if infix.Token.Position() != "3:9" {
+		t.Errorf("untouched infix expression lost its position. got = %s", infix.Token.Position())
+	}
+
+	if infix.String() != "(x + 5)" {
+		t.Errorf("infix.String() is not %q. got = %q", "(x + 5)", infix.String())
+	}
+}
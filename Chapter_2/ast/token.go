@@ -0,0 +1,66 @@
+package ast
+
+import "Chapter_2/token"
+
+// A function to return the token of a node, which gives the position of the node
+// The token of a Program, or of a node of an unknown type, is the zero token
+func TokenOf(node Node) token.Token {
+	switch node := node.(type) {
+	// Expressions
+	case *Variable:
+		return node.Token
+	case *IntegerLiteral:
+		return node.Token
+	case *FloatLiteral:
+		return node.Token
+	case *Boolean:
+		return node.Token
+	case *StringLiteral:
+		return node.Token
+	case *PrefixExpression:
+		return node.Token
+	case *InfixExpression:
+		return node.Token
+	case *IndexExpression:
+		return node.Token
+	case *AssignExpression:
+		return node.Token
+	case *ArrayLiteral:
+		return node.Token
+	case *IfExpression:
+		return node.Token
+	case *FunctionLiteral:
+		return node.Token
+	case *CallExpression:
+		return node.Token
+	case *ImportExpression:
+		return node.Token
+
+	// Statements
+	case *LetStatement:
+		return node.Token
+	case *ExportStatement:
+		return node.Token
+	case *ReturnStatement:
+		return node.Token
+	case *ExpressionStatement:
+		return node.Token
+	case *BlockStatement:
+		return node.Token
+	case *ThrowStatement:
+		return node.Token
+	case *TryStatement:
+		return node.Token
+	case *WhileStatement:
+		return node.Token
+	case *ForStatement:
+		return node.Token
+	case *BreakStatement:
+		return node.Token
+	case *ContinueStatement:
+		return node.Token
+	case *Comment:
+		return node.Token
+	}
+	return token.Token{}
+}
@@ -0,0 +1,184 @@
+package ast
+
+// Traversal of the AST, modelled on go/ast
+
+import "fmt"
+
+// A Visitor's Visit method is called for every node reached by Walk
+// If the returned visitor w is not nil,
+// Walk visits each child of the node with w, followed by a call of w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// A function to traverse an AST in depth-first order
+// It starts by calling v.Visit(node) and then walks the children in source order
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	// Expressions
+	case *Variable, *IntegerLiteral, *FloatLiteral, *Boolean, *StringLiteral:
+		// Nothing to walk
+
+	case *PrefixExpression:
+		walkExpression(v, n.Right)
+
+	case *InfixExpression:
+		walkExpression(v, n.LeftValue)
+		walkExpression(v, n.RightValue)
+
+	case *IndexExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Index)
+
+	case *AssignExpression:
+		walkExpression(v, n.Target)
+		walkExpression(v, n.Value)
+
+	case *ArrayLiteral:
+		for _, element := range n.Elements {
+			walkExpression(v, element)
+		}
+
+	case *IfExpression:
+		walkExpression(v, n.Condition)
+		if n.Consequence != nil {
+			Walk(v, n.Consequence)
+		}
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+
+	case *FunctionLiteral:
+		for _, parameter := range n.Parameters {
+			Walk(v, parameter)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *CallExpression:
+		walkExpression(v, n.Function)
+		for _, argument := range n.Arguments {
+			walkExpression(v, argument)
+		}
+
+	case *ImportExpression:
+		if n.Path != nil {
+			Walk(v, n.Path)
+		}
+
+	// Statements
+	case *LetStatement:
+		if n.Variable != nil {
+			Walk(v, n.Variable)
+		}
+		walkExpression(v, n.Expression)
+
+	case *ExportStatement:
+		if n.Statement != nil {
+			Walk(v, n.Statement)
+		}
+
+	case *ReturnStatement:
+		walkExpression(v, n.ReturnValue)
+
+	case *ExpressionStatement:
+		walkExpression(v, n.Expression)
+
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+
+	case *ThrowStatement:
+		walkExpression(v, n.Value)
+
+	case *TryStatement:
+		if n.Block != nil {
+			Walk(v, n.Block)
+		}
+		if n.CatchParam != nil {
+			Walk(v, n.CatchParam)
+		}
+		if n.Catch != nil {
+			Walk(v, n.Catch)
+		}
+		if n.Finally != nil {
+			Walk(v, n.Finally)
+		}
+
+	case *WhileStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+		walkExpression(v, n.Condition)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *ForStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+		if n.Variable != nil {
+			Walk(v, n.Variable)
+		}
+		walkExpression(v, n.Iterable)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *BreakStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+
+	case *ContinueStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+
+	// Program
+	case *Program:
+		walkStatements(v, n.Statements)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+// A function to walk an expression that may be missing
+func walkExpression(v Visitor, expression Expression) {
+	if expression != nil {
+		Walk(v, expression)
+	}
+}
+
+// A function to walk a list of statements in order
+func walkStatements(v Visitor, statements []Statement) {
+	for _, s := range statements {
+		Walk(v, s)
+	}
+}
+
+// A function type that is a Visitor
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// A function to traverse an AST in depth-first order
+// It starts by calling f(node); node must not be nil
+// If f returns true, Inspect invokes f recursively for each of the children of node,
+// followed by a call of f(nil)
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,192 @@
+package ast
+
+import (
+	"Chapter_2/token"
+	"fmt"
+	"testing"
+)
+
+func newVariable(name string) *Variable {
+	return &Variable{Token: token.Token{Type: token.VARIABLE, Literal: name}, Literal: name}
+}
+
+func newInteger(value int64) *IntegerLiteral {
+	return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: fmt.Sprintf("%d", value)}, Value: value}
+}
+
+func newBlock(statements ...Statement) *BlockStatement {
+	return &BlockStatement{Token: token.Token{Type: token.PLBRACKET, Literal: "{"}, Statements: statements}
+}
+
+func newExpressionStatement(expression Expression) *ExpressionStatement {
+	return &ExpressionStatement{Token: token.Token{Literal: expression.TokenLiteral()}, Expression: expression}
+}
+
+// The program:
+//
+//	let x = 1;
+//	return -x;
+//	x + 2;
+//	xs[0] = 3;
+//	throw x;
+//	try { x; } catch (e) { e; } finally { x; }
+//	outer: while (x) { break outer; }
+//	for (i in xs) { continue; }
+func newWalkProgram() *Program {
+	return &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token:      token.Token{Type: token.LET, Literal: "let"},
+				Variable:   newVariable("x"),
+				Expression: newInteger(1),
+			},
+			&ReturnStatement{
+				Token:       token.Token{Type: token.RETURN, Literal: "return"},
+				ReturnValue: &PrefixExpression{Token: token.Token{Type: token.MINUS, Literal: "-"}, Operator: "-", Right: newVariable("x")},
+			},
+			newExpressionStatement(&InfixExpression{
+				Token:      token.Token{Type: token.PLUS, Literal: "+"},
+				LeftValue:  newVariable("x"),
+				Operator:   "+",
+				RightValue: newInteger(2),
+			}),
+			newExpressionStatement(&AssignExpression{
+				Token:    token.Token{Type: token.ASSIGN, Literal: "="},
+				Target:   &IndexExpression{Token: token.Token{Type: token.SLBRACKET, Literal: "["}, Left: newVariable("xs"), Index: newInteger(0)},
+				Operator: "=",
+				Value:    newInteger(3),
+			}),
+			&ThrowStatement{Token: token.Token{Type: token.THROW, Literal: "throw"}, Value: newVariable("x")},
+			&TryStatement{
+				Token:      token.Token{Type: token.TRY, Literal: "try"},
+				Block:      newBlock(newExpressionStatement(newVariable("x"))),
+				CatchParam: newVariable("e"),
+				Catch:      newBlock(newExpressionStatement(newVariable("e"))),
+				Finally:    newBlock(newExpressionStatement(newVariable("x"))),
+			},
+			&WhileStatement{
+				Token:     token.Token{Type: token.WHILE, Literal: "while"},
+				Label:     newVariable("outer"),
+				Condition: newVariable("x"),
+				Body:      newBlock(&BreakStatement{Token: token.Token{Type: token.BREAK, Literal: "break"}, Label: newVariable("outer")}),
+			},
+			&ForStatement{
+				Token:    token.Token{Type: token.FOR, Literal: "for"},
+				Variable: newVariable("i"),
+				Iterable: newVariable("xs"),
+				Body:     newBlock(&ContinueStatement{Token: token.Token{Type: token.CONTINUE, Literal: "continue"}}),
+			},
+		},
+	}
+}
+
+func TestInspectSourceOrder(t *testing.T) {
+	expected := []string{
+		"*ast.Program",
+		"*ast.LetStatement let",
+		"*ast.Variable x",
+		"*ast.IntegerLiteral 1",
+		"*ast.ReturnStatement return",
+		"*ast.PrefixExpression -",
+		"*ast.Variable x",
+		"*ast.ExpressionStatement +",
+		"*ast.InfixExpression +",
+		"*ast.Variable x",
+		"*ast.IntegerLiteral 2",
+		"*ast.ExpressionStatement =",
+		"*ast.AssignExpression =",
+		"*ast.IndexExpression [",
+		"*ast.Variable xs",
+		"*ast.IntegerLiteral 0",
+		"*ast.IntegerLiteral 3",
+		"*ast.ThrowStatement throw",
+		"*ast.Variable x",
+		"*ast.TryStatement try",
+		"*ast.BlockStatement {",
+		"*ast.ExpressionStatement x",
+		"*ast.Variable x",
+		"*ast.Variable e",
+		"*ast.BlockStatement {",
+		"*ast.ExpressionStatement e",
+		"*ast.Variable e",
+		"*ast.BlockStatement {",
+		"*ast.ExpressionStatement x",
+		"*ast.Variable x",
+		"*ast.WhileStatement while",
+		"*ast.Variable outer",
+		"*ast.Variable x",
+		"*ast.BlockStatement {",
+		"*ast.BreakStatement break",
+		"*ast.Variable outer",
+		"*ast.ForStatement for",
+		"*ast.Variable i",
+		"*ast.Variable xs",
+		"*ast.BlockStatement {",
+		"*ast.ContinueStatement continue",
+	}
+
+	visited := []string{}
+	Inspect(newWalkProgram(), func(node Node) bool {
+		if node == nil {
+			return false
+		}
+		if _, ok := node.(*Program); ok {
+			visited = append(visited, "*ast.Program")
+		} else {
+			visited = append(visited, fmt.Sprintf("%T %s", node, node.TokenLiteral()))
+		}
+		return true
+	})
+
+	if len(visited) != len(expected) {
+		t.Fatalf("visited %d nodes, expected %d. got = %v", len(visited), len(expected), visited)
+	}
+
+	for i, want := range expected {
+		if visited[i] != want {
+			t.Errorf("Failed at [%d] - expected %q, got %q", i, want, visited[i])
+		}
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	count := 0
+	Inspect(newWalkProgram(), func(node Node) bool {
+		if node == nil {
+			return false
+		}
+		count++
+		// Do not descend into any statement
+		_, isProgram := node.(*Program)
+		return isProgram
+	})
+
+	// The program and its 8 statements
+	if count != 9 {
+		t.Errorf("visited %d nodes, expected 9", count)
+	}
+}
+
+// A Visitor that counts the calls of Visit(nil)
+type closingVisitor struct {
+	opened *int
+	closed *int
+}
+
+func (cv closingVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		*cv.closed++
+	} else {
+		*cv.opened++
+	}
+	return cv
+}
+
+func TestWalkClosesEveryNode(t *testing.T) {
+	opened, closed := 0, 0
+	Walk(closingVisitor{opened: &opened, closed: &closed}, newWalkProgram())
+
+	if opened != closed {
+		t.Errorf("Visit(nil) was called %d times for %d nodes", closed, opened)
+	}
+}
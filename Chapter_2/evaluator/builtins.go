@@ -0,0 +1,120 @@
+package evaluator
+
+import (
+	"Chapter_2/object"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// The functions every program can call without declaring them
+var builtins = map[string]*object.Builtin{
+	"len": {Name: "len", Fn: func(args ...object.Object) object.Object {
+		if err := checkArguments(args, 1); err != nil {
+			return err
+		}
+
+		switch arg := args[0].(type) {
+		case *object.Array:
+			return &object.Integer{Value: int64(len(arg.Elements))}
+		case *object.String:
+			// The length of a string is its number of characters, not of bytes
+			return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+		default:
+			return newBuiltinError("argument to `len` not supported, got %s", args[0].Type())
+		}
+	}},
+
+	"puts": {Name: "puts", Fn: func(args ...object.Object) object.Object {
+		for _, arg := range args {
+			fmt.Println(arg.Inspect())
+		}
+		return NULL
+	}},
+
+	"first": {Name: "first", Fn: func(args ...object.Object) object.Object {
+		array, err := arrayArgument("first", args)
+		if err != nil {
+			return err
+		}
+		if len(array.Elements) > 0 {
+			return array.Elements[0]
+		}
+		return NULL
+	}},
+
+	"last": {Name: "last", Fn: func(args ...object.Object) object.Object {
+		array, err := arrayArgument("last", args)
+		if err != nil {
+			return err
+		}
+		if length := len(array.Elements); length > 0 {
+			return array.Elements[length-1]
+		}
+		return NULL
+	}},
+
+	"rest": {Name: "rest", Fn: func(args ...object.Object) object.Object {
+		array, err := arrayArgument("rest", args)
+		if err != nil {
+			return err
+		}
+		if length := len(array.Elements); length > 0 {
+			elements := make([]object.Object, length-1)
+			copy(elements, array.Elements[1:])
+			return &object.Array{Elements: elements}
+		}
+		return NULL
+	}},
+
+	// push returns a new array, the array it is given does not change
+	"push": {Name: "push", Fn: func(args ...object.Object) object.Object {
+		if err := checkArguments(args, 2); err != nil {
+			return err
+		}
+		array, ok := args[0].(*object.Array)
+		if !ok {
+			return newBuiltinError("argument to `push` must be ARRAY, got %s", args[0].Type())
+		}
+
+		elements := make([]object.Object, len(array.Elements), len(array.Elements)+1)
+		copy(elements, array.Elements)
+		return &object.Array{Elements: append(elements, args[1])}
+	}},
+}
+
+// A function to return the names of the builtins, sorted
+// The resolver, the linter and the language server take them as the names programs may use
+func BuiltinNames() []string {
+	names := []string{}
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A function to return the array argument of a builtin that takes only an array
+func arrayArgument(name string, args []object.Object) (*object.Array, *object.Error) {
+	if err := checkArguments(args, 1); err != nil {
+		return nil, err
+	}
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, newBuiltinError("argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+	return array, nil
+}
+
+func checkArguments(args []object.Object, want int) *object.Error {
+	if len(args) != want {
+		return newBuiltinError("wrong number of arguments: want=%d, got=%d", want, len(args))
+	}
+	return nil
+}
+
+// A function to make the error of a builtin
+// It has no position, the evaluator raises it at the call
+func newBuiltinError(format string, a ...any) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
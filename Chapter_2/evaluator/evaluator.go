@@ -0,0 +1,800 @@
+package evaluator
+
+// A tree-walking evaluator
+//
+// Eval returns the value of a node, or a signal that unwinds the evaluation:
+// a ReturnValue up to the function, a Break or a Continue up to the loop,
+// and an Exception up to the try statement that catches it or the top level
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/object"
+	"Chapter_2/optimizer"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// The values there is only one of
+var (
+	NULL  = &object.Null{}
+	TRUE  = &object.Boolean{Value: true}
+	FALSE = &object.Boolean{Value: false}
+)
+
+// The number of nested calls after which a call fails, before the Go stack runs out
+const MaxDepth = 10000
+
+// A function to evaluate a program, optimized first if optimize is set
+// It returns the value of the last statement, nil if it has none,
+// and the error of an exception nothing caught
+// An environment without an importer gets a Loader without a search path
+func EvalProgram(program *ast.Program, env *object.Environment, optimize bool) (object.Object, *object.Error) {
+	if optimize {
+		program = optimizer.Optimize(program).(*ast.Program)
+	}
+	if env.Importer() == nil {
+		loader := NewLoader()
+		loader.Optimize = optimize
+		env.SetImporter(loader)
+	}
+
+	result := Eval(program, env)
+	if exception, ok := result.(*object.Exception); ok {
+		return nil, exception.Error
+	}
+	return result, nil
+}
+
+// A function to evaluate a node in an environment
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+	// Statements
+	case *ast.Program:
+		return evalProgram(node, env)
+
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, object.NewEnclosedEnvironment(env))
+
+	case *ast.LetStatement:
+		value := Eval(node.Expression, env)
+		if isSignal(value) {
+			return value
+		}
+		// A function is named after the first let it is bound to, for stack traces
+		if function, ok := value.(*object.Function); ok && function.Name == "" {
+			function.Name = node.Variable.Literal
+		}
+		env.Set(node.Variable.Literal, value)
+		return nil
+
+	case *ast.ExportStatement:
+		return Eval(node.Statement, env)
+
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{Value: NULL}
+		}
+		value := Eval(node.ReturnValue, env)
+		if isSignal(value) {
+			return value
+		}
+		return &object.ReturnValue{Value: value}
+
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+
+	case *ast.TryStatement:
+		return evalTryStatement(node, env)
+
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+
+	case *ast.BreakStatement:
+		return &object.Break{Label: labelOf(node.Label)}
+
+	case *ast.ContinueStatement:
+		return &object.Continue{Label: labelOf(node.Label)}
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return object.NewBigInteger(node.Big)
+		}
+		return &object.Integer{Value: node.Value}
+
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+
+	case *ast.ImportExpression:
+		importer := env.Importer()
+		if importer == nil {
+			return newError(node, env, "cannot import %s: no importer", node.Path.Value)
+		}
+		return importer.Import(node, env)
+
+	case *ast.Variable:
+		return evalVariable(node, env)
+
+	case *ast.PrefixExpression:
+		right := Eval(node.Right, env)
+		if isSignal(right) {
+			return right
+		}
+		return evalPrefixExpression(node, right, env)
+
+	case *ast.InfixExpression:
+		left := Eval(node.LeftValue, env)
+		if isSignal(left) {
+			return left
+		}
+		right := Eval(node.RightValue, env)
+		if isSignal(right) {
+			return right
+		}
+		return evalInfixExpression(node, node.Operator, left, right, env)
+
+	case *ast.IfExpression:
+		return evalIfExpression(node, env)
+
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isSignal(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isSignal(left) {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isSignal(index) {
+			return index
+		}
+		return evalIndexExpression(node, left, index, env)
+
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+
+	case *ast.FunctionLiteral:
+		return &object.Function{Parameters: node.Parameters, Body: node.Body, Env: env, File: env.File()}
+
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isSignal(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isSignal(args[0]) {
+			return args[0]
+		}
+		return applyFunction(node, function, args, env)
+	}
+
+	return nil
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range program.Statements {
+		result = Eval(statement, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Exception:
+			return result
+		}
+	}
+
+	return result
+}
+
+// A function to evaluate the statements of a block in the environment of the block
+// A signal stops the block and is returned as it is
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+		if isSignal(result) {
+			return result
+		}
+	}
+
+	return result
+}
+
+func evalVariable(variable *ast.Variable, env *object.Environment) object.Object {
+	if value, ok := env.Get(variable.Literal); ok {
+		return value
+	}
+	if builtin, ok := builtins[variable.Literal]; ok {
+		return builtin
+	}
+	return newError(variable, env, "undefined variable %s", variable.Literal)
+}
+
+func evalPrefixExpression(node *ast.PrefixExpression, right object.Object, env *object.Environment) object.Object {
+	switch node.Operator {
+	case "!":
+		return nativeBoolToBooleanObject(!isTruthy(right))
+	case "-":
+		if float, ok := right.(*object.Float); ok {
+			return &object.Float{Value: -float.Value}
+		}
+		integer, ok := right.(*object.Integer)
+		if !ok {
+			return newError(node, env, "unknown operator: -%s", right.Type())
+		}
+		// The negation of the smallest int64 does not fit in an int64
+		if integer.Big != nil || integer.Value == math.MinInt64 {
+			return object.NewBigInteger(new(big.Int).Neg(integer.BigValue()))
+		}
+		return &object.Integer{Value: -integer.Value}
+	default:
+		return newError(node, env, "unknown operator: %s%s", node.Operator, right.Type())
+	}
+}
+
+// A function to evaluate an infix operation, node gives the position of errors
+func evalInfixExpression(node ast.Node, operator string, left, right object.Object, env *object.Environment) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(node, operator, left.(*object.Integer), right.(*object.Integer), env)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(node, operator, left, right, env)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(node, operator, left.(*object.String), right.(*object.String), env)
+	// The other values are only equal to themselves, and there is only one true, false and null
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	case left.Type() != right.Type():
+		return newError(node, env, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return newError(node, env, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// A function to evaluate an operation on two integers
+// The operation is done on int64 when the integers and the result fit, and on big.Int otherwise
+func evalIntegerInfixExpression(node ast.Node, operator string, left, right *object.Integer, env *object.Environment) object.Object {
+	if operator == "/" && right.Big == nil && right.Value == 0 {
+		return newError(node, env, "division by zero")
+	}
+
+	if left.Big == nil && right.Big == nil {
+		if result, ok := smallIntegerOperation(operator, left.Value, right.Value); ok {
+			return result
+		}
+	}
+
+	a, b := left.BigValue(), right.BigValue()
+	switch operator {
+	case "+":
+		return object.NewBigInteger(new(big.Int).Add(a, b))
+	case "-":
+		return object.NewBigInteger(new(big.Int).Sub(a, b))
+	case "*":
+		return object.NewBigInteger(new(big.Int).Mul(a, b))
+	case "/":
+		// Quo truncates towards zero, like Go's integer division
+		return object.NewBigInteger(new(big.Int).Quo(a, b))
+	case "<":
+		return nativeBoolToBooleanObject(a.Cmp(b) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(a.Cmp(b) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(a.Cmp(b) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(a.Cmp(b) != 0)
+	default:
+		return newError(node, env, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// A function to evaluate an operation on two numbers, one of them a float
+// The integer is converted to a float for the arithmetic, and compared exactly
+func evalFloatInfixExpression(node ast.Node, operator string, left, right object.Object, env *object.Environment) object.Object {
+	switch operator {
+	case "<":
+		return nativeBoolToBooleanObject(object.CompareNumbers(left, right) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(object.CompareNumbers(left, right) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(object.CompareNumbers(left, right) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(object.CompareNumbers(left, right) != 0)
+	case "+", "-", "*", "/":
+	default:
+		return newError(node, env, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	a, ok := floatValue(left)
+	if !ok {
+		return newError(node, env, "integer too big for a float: %s %s %s", left.Type(), operator, right.Type())
+	}
+	b, ok := floatValue(right)
+	if !ok {
+		return newError(node, env, "integer too big for a float: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	var result float64
+	switch operator {
+	case "+":
+		result = a + b
+	case "-":
+		result = a - b
+	case "*":
+		result = a * b
+	case "/":
+		if b == 0 {
+			return newError(node, env, "division by zero")
+		}
+		result = a / b
+	}
+
+	if math.IsInf(result, 0) {
+		return newError(node, env, "float overflow: %s %s %s", left.Type(), operator, right.Type())
+	}
+	return &object.Float{Value: result}
+}
+
+// A function to tell whether a value is an integer or a float
+func isNumber(value object.Object) bool {
+	return value.Type() == object.INTEGER_OBJ || value.Type() == object.FLOAT_OBJ
+}
+
+// A function to return a number as a float64, false if it is an integer too big for one
+func floatValue(number object.Object) (float64, bool) {
+	if integer, ok := number.(*object.Integer); ok {
+		return integer.Float64()
+	}
+	return number.(*object.Float).Value, true
+}
+
+// A function to evaluate an operation on two strings
+// Strings are joined with "+", and compared by their bytes
+func evalStringInfixExpression(node ast.Node, operator string, left, right *object.String, env *object.Environment) object.Object {
+	switch operator {
+	case "+":
+		return &object.String{Value: left.Value + right.Value}
+	case "<":
+		return nativeBoolToBooleanObject(left.Value < right.Value)
+	case ">":
+		return nativeBoolToBooleanObject(left.Value > right.Value)
+	case "==":
+		return nativeBoolToBooleanObject(left.Value == right.Value)
+	case "!=":
+		return nativeBoolToBooleanObject(left.Value != right.Value)
+	default:
+		return newError(node, env, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// A function to do an operation on two int64
+// It returns false if the result overflows or the operator is unknown
+func smallIntegerOperation(operator string, a, b int64) (object.Object, bool) {
+	switch operator {
+	case "+":
+		sum := a + b
+		if (a > 0 && b > 0 && sum < 0) || (a < 0 && b < 0 && sum >= 0) {
+			return nil, false
+		}
+		return &object.Integer{Value: sum}, true
+	case "-":
+		difference := a - b
+		if (a >= 0 && b < 0 && difference < 0) || (a < 0 && b > 0 && difference >= 0) {
+			return nil, false
+		}
+		return &object.Integer{Value: difference}, true
+	case "*":
+		if a == 0 || b == 0 {
+			return &object.Integer{Value: 0}, true
+		}
+		product := a * b
+		if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+			return nil, false
+		}
+		return &object.Integer{Value: product}, true
+	case "/":
+		if a == math.MinInt64 && b == -1 {
+			return nil, false
+		}
+		return &object.Integer{Value: a / b}, true
+	case "<":
+		return nativeBoolToBooleanObject(a < b), true
+	case ">":
+		return nativeBoolToBooleanObject(a > b), true
+	case "==":
+		return nativeBoolToBooleanObject(a == b), true
+	case "!=":
+		return nativeBoolToBooleanObject(a != b), true
+	}
+	return nil, false
+}
+
+func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := Eval(ie.Condition, env)
+	if isSignal(condition) {
+		return condition
+	}
+
+	var result object.Object
+	switch {
+	case isTruthy(condition):
+		result = Eval(ie.Consequence, env)
+	case ie.Alternative != nil:
+		result = Eval(ie.Alternative, env)
+	}
+
+	// A branch without a value, e.g. ending with a let, gives null
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+func evalIndexExpression(node ast.Node, left, index object.Object, env *object.Environment) object.Object {
+	if module, ok := left.(*object.Module); ok {
+		return evalModuleIndexExpression(node, module, index, env)
+	}
+	if err, ok := left.(*object.Error); ok {
+		return evalErrorIndexExpression(node, err, index, env)
+	}
+
+	array, ok := left.(*object.Array)
+	if !ok {
+		return newError(node, env, "index operator not supported: %s", left.Type())
+	}
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		return newError(node, env, "index is not an integer: %s", index.Type())
+	}
+
+	// An index out of range gives null
+	if integer.Big != nil || integer.Value < 0 || integer.Value >= int64(len(array.Elements)) {
+		return NULL
+	}
+	return array.Elements[integer.Value]
+}
+
+// A function to read an exported binding of a module, e.g. util["f"]
+func evalModuleIndexExpression(node ast.Node, module *object.Module, index object.Object, env *object.Environment) object.Object {
+	name, ok := index.(*object.String)
+	if !ok {
+		return newError(node, env, "index of a module is not a string: %s", index.Type())
+	}
+	value, ok := module.Export(name.Value)
+	if !ok {
+		return newError(node, env, "%s is not exported by %s", name.Value, module.Name)
+	}
+	return value
+}
+
+// A function to read a field of a caught error, e.g. e["message"]
+// "message" gives its message and "stack" its stack trace
+func evalErrorIndexExpression(node ast.Node, err *object.Error, index object.Object, env *object.Environment) object.Object {
+	name, ok := index.(*object.String)
+	if !ok {
+		return newError(node, env, "index of an error is not a string: %s", index.Type())
+	}
+	switch name.Value {
+	case "message":
+		return &object.String{Value: err.Message}
+	case "stack":
+		return &object.String{Value: err.StackTrace()}
+	}
+	return newError(node, env, "an error has no field %s", name.Value)
+}
+
+// A function to evaluate an assignment to a variable or an array element
+// A compound assignment, e.g. "x += 1", applies its operator to the current value first
+// There are no hashes, so an index assignment by key, e.g. h["k"] = v, is a runtime error
+func evalAssignExpression(ae *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := ae.Target.(type) {
+	case *ast.Variable:
+		if _, ok := env.Get(target.Literal); !ok {
+			return newError(target, env, "assignment to undefined variable %s", target.Literal)
+		}
+		value := evalAssignedValue(ae, func() object.Object { return evalVariable(target, env) }, env)
+		if isSignal(value) {
+			return value
+		}
+		env.Assign(target.Literal, value)
+		return value
+
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isSignal(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isSignal(index) {
+			return index
+		}
+
+		array, ok := left.(*object.Array)
+		if !ok {
+			return newError(target, env, "index assignment not supported: %s", left.Type())
+		}
+		integer, ok := index.(*object.Integer)
+		if !ok {
+			return newError(target, env, "index is not an integer: %s", index.Type())
+		}
+		if integer.Big != nil || integer.Value < 0 || integer.Value >= int64(len(array.Elements)) {
+			return newError(target, env, "index out of range: %s with length %d", integer.Inspect(), len(array.Elements))
+		}
+
+		value := evalAssignedValue(ae, func() object.Object { return array.Elements[integer.Value] }, env)
+		if isSignal(value) {
+			return value
+		}
+		array.Elements[integer.Value] = value
+		return value
+	}
+
+	return newError(ae, env, "cannot assign to %s", ae.Target.String())
+}
+
+// A function to evaluate the value an assignment stores, current gives the value it replaces
+func evalAssignedValue(ae *ast.AssignExpression, current func() object.Object, env *object.Environment) object.Object {
+	value := Eval(ae.Value, env)
+	if isSignal(value) || ae.Operator == "=" {
+		return value
+	}
+
+	// "+=" applies "+", and so on
+	operator := ae.Operator[:len(ae.Operator)-1]
+	return evalInfixExpression(ae, operator, current(), value, env)
+}
+
+func evalThrowStatement(ts *ast.ThrowStatement, env *object.Environment) object.Object {
+	value := Eval(ts.Value, env)
+	if isSignal(value) {
+		return value
+	}
+
+	// A caught error is thrown again with the stack trace of where it was raised
+	if err, ok := value.(*object.Error); ok {
+		return &object.Exception{Error: err}
+	}
+
+	err := newError(ts, env, "%s", value.Inspect())
+	err.Error.Value = value
+	return err
+}
+
+// A function to evaluate a try statement
+// The catch block gets the thrown value, or the error of a failure
+// The finally block always runs, and a signal from it replaces the one of the other blocks
+func evalTryStatement(ts *ast.TryStatement, env *object.Environment) object.Object {
+	result := Eval(ts.Block, env)
+
+	if exception, ok := result.(*object.Exception); ok && ts.Catch != nil {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		if exception.Error.Value != nil {
+			catchEnv.Set(ts.CatchParam.Literal, exception.Error.Value)
+		} else {
+			catchEnv.Set(ts.CatchParam.Literal, exception.Error)
+		}
+		result = evalBlockStatement(ts.Catch, catchEnv)
+	}
+
+	if ts.Finally != nil {
+		if finally := Eval(ts.Finally, env); isSignal(finally) {
+			return finally
+		}
+	}
+
+	return result
+}
+
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	label := labelOf(ws.Label)
+
+	for {
+		condition := Eval(ws.Condition, env)
+		if isSignal(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		result := Eval(ws.Body, env)
+		if stop, signal := loopControl(result, label); stop {
+			return signal
+		}
+	}
+}
+
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	label := labelOf(fs.Label)
+
+	iterable := Eval(fs.Iterable, env)
+	if isSignal(iterable) {
+		return iterable
+	}
+	array, ok := iterable.(*object.Array)
+	if !ok {
+		return newError(fs.Iterable, env, "cannot iterate over %s", iterable.Type())
+	}
+
+	for _, element := range array.Elements {
+		// Every iteration has its own variable, so closures keep the element they saw
+		bodyEnv := object.NewEnclosedEnvironment(env)
+		bodyEnv.Set(fs.Variable.Literal, element)
+
+		result := evalBlockStatement(fs.Body, bodyEnv)
+		if stop, signal := loopControl(result, label); stop {
+			return signal
+		}
+	}
+
+	return nil
+}
+
+// A function to handle the result of the body of a loop
+// It returns true if the loop stops, with the signal the loop returns
+func loopControl(result object.Object, label string) (bool, object.Object) {
+	switch result := result.(type) {
+	case *object.Break:
+		if result.Label == "" || result.Label == label {
+			return true, nil
+		}
+		return true, result
+	case *object.Continue:
+		if result.Label == "" || result.Label == label {
+			return false, nil
+		}
+		return true, result
+	case *object.ReturnValue, *object.Exception:
+		return true, result
+	}
+	return false, nil
+}
+
+// A function to evaluate expressions from left to right
+// It returns only the signal if one of them is a signal
+func evalExpressions(expressions []ast.Expression, env *object.Environment) []object.Object {
+	result := []object.Object{}
+
+	for _, expression := range expressions {
+		evaluated := Eval(expression, env)
+		if isSignal(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+// A function to call a function or a builtin
+// A call of a function pushes a frame, so the errors raised inside it have a stack trace
+func applyFunction(call *ast.CallExpression, function object.Object, args []object.Object, env *object.Environment) object.Object {
+	switch function := function.(type) {
+	case *object.Function:
+		if len(args) != len(function.Parameters) {
+			return newError(call, env, "wrong number of arguments: want=%d, got=%d", len(function.Parameters), len(args))
+		}
+
+		caller := env.Frame()
+		frame := &object.Frame{
+			Function: function.Name,
+			File:     env.File(),
+			Line:     call.Token.Line,
+			Column:   call.Token.Column,
+			Caller:   caller,
+			Depth:    1,
+		}
+		if frame.Function == "" {
+			frame.Function = "fn"
+		}
+		if caller != nil {
+			frame.Depth = caller.Depth + 1
+		}
+		if frame.Depth > MaxDepth {
+			return newError(call, env, "stack overflow: more than %d nested calls", MaxDepth)
+		}
+
+		callEnv := object.NewEnclosedEnvironment(function.Env)
+		callEnv.SetFrame(frame)
+		// The body is in the file of the function, whichever file its environment is evaluating now
+		callEnv.SetFile(function.File)
+		for i, parameter := range function.Parameters {
+			callEnv.Set(parameter.Literal, args[i])
+		}
+
+		result := evalBlockStatement(function.Body, callEnv)
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case nil:
+			return NULL
+		}
+		return result
+
+	case *object.Builtin:
+		result := function.Fn(args...)
+		// The error of a builtin is raised at the call
+		if err, ok := result.(*object.Error); ok && err.Line == 0 {
+			exception := newError(call, env, "%s", err.Message)
+			exception.Error.Value = err.Value
+			return exception
+		}
+		if result == nil {
+			return NULL
+		}
+		return result
+
+	default:
+		return newError(call, env, "not a function: %s", function.Type())
+	}
+}
+
+// A function to raise an error at the position of a node
+func newError(node ast.Node, env *object.Environment, format string, a ...any) *object.Exception {
+	tok := ast.TokenOf(node)
+	return &object.Exception{Error: &object.Error{
+		Message: fmt.Sprintf(format, a...),
+		File:    env.File(),
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Frame:   env.Frame(),
+	}}
+}
+
+// A function to report whether an object unwinds the evaluation
+func isSignal(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+	switch obj.Type() {
+	case object.RETURN_VALUE_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ, object.EXCEPTION_OBJ:
+		return true
+	}
+	return false
+}
+
+// null and false are false, every other value is true
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL, FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+func labelOf(label *ast.Variable) string {
+	if label == nil {
+		return ""
+	}
+	return label.Literal
+}
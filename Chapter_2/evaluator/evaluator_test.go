@@ -0,0 +1,514 @@
+package evaluator
+
+import (
+	"Chapter_2/lexer"
+	"Chapter_2/object"
+	"Chapter_2/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A function to evaluate some source in a new environment
+// An uncaught error is returned as the value
+func testEval(t *testing.T, input string) object.Object {
+	p := parser.NewParser(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser has errors for %q: %v", input, p.Errors())
+	}
+
+	result, err := EvalProgram(program, object.NewEnvironment(), false)
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+func testInspect(t *testing.T, tests []struct {
+	input    string
+	expected string
+}) {
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated == nil {
+			t.Errorf("%q has no value, expected %q", tt.input, tt.expected)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q is not %q. got = %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvalIntegerExpression(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"5", "5"},
+		{"-10", "-10"},
+		{"5 + 5 + 5 + 5 - 10", "10"},
+		{"2 * (5 + 10)", "30"},
+		{"-50 + 100 + -50", "0"},
+		{"7 / 2", "3"},
+		{"-7 / 2", "-3"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"18446744073709551616 / 4294967296", "4294967296"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"9223372036854775808 - 1", "9223372036854775807"},
+	})
+}
+
+func TestBigIntegersAreNormalized(t *testing.T) {
+	integer, ok := testEval(t, "9223372036854775808 - 1").(*object.Integer)
+	if !ok || integer.Big != nil {
+		t.Errorf("an integer that fits in an int64 is not stored as one. got = %+v", integer)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"true", "true"},
+		{"1 < 2", "true"},
+		{"1 > 2", "false"},
+		{"9223372036854775808 > 1", "true"},
+		{"9223372036854775808 == 9223372036854775807 + 1", "true"},
+		{"true == true", "true"},
+		{"true != false", "true"},
+		{"(1 < 2) == true", "true"},
+		{"!true", "false"},
+		{"!!5", "true"},
+		{"1 == true", "false"},
+	})
+}
+
+func TestStrings(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`"tab\there"`, "tab\there"},
+		{`"a" == "a"`, "true"},
+		{`"a" != "a"`, "false"},
+		{`"a" < "b"`, "true"},
+		{`"a" == 1`, "false"},
+		{`len("héllo")`, "5"},
+		{`len("")`, "0"},
+		{`["a", "b"]`, "[a, b]"},
+		{`"a" - "b"`, "ERROR: unknown operator: STRING - STRING"},
+		{`"a" + 1`, "ERROR: type mismatch: STRING + INTEGER"},
+	})
+}
+
+func TestFloats(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"3.14", "3.14"},
+		{"-2.5", "-2.5"},
+		{"1.5 + 1.5", "3.0"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1 + 0.5", "1.5"},
+		{"7 / 2.0", "3.5"},
+		{"2.0 * 3", "6.0"},
+		{"1e21 * 10", "1e+22"},
+		{"let x = 1; x += 0.5; x", "1.5"},
+		{"1 == 1.0", "true"},
+		{"1.5 < 2", "true"},
+		{"2 > 2.5", "false"},
+		{"0.1 + 0.2 != 0.3", "true"},
+		// The integers are compared exactly, not as floats
+		{"9007199254740993 == 9007199254740992.0", "false"},
+		{"100000000000000000000 > 1e19", "true"},
+		{"1.0 / 0", "ERROR: division by zero"},
+		{"1e308 * 10", "ERROR: float overflow: FLOAT * INTEGER"},
+		{"1.5 + " + strings.Repeat("9", 400), "ERROR: integer too big for a float: FLOAT + INTEGER"},
+		{"1.5 + true", "ERROR: type mismatch: FLOAT + BOOLEAN"},
+		{"!1.5", "false"},
+	})
+}
+
+func TestIfExpressions(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"if (true) { 10 }", "10"},
+		{"if (false) { 10 }", "null"},
+		{"if (1) { 10 }", "10"},
+		{"if (1 > 2) { 10 } else { 20 }", "20"},
+		{"if (true) { let x = 1; }", "null"},
+	})
+}
+
+func TestFunctions(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", "5"},
+		{"let double = fn(x) { return x * 2; 0; }; double(5);", "10"},
+		{"fn(x) { x; }(5)", "5"},
+		{"let newAdder = fn(x) { fn(y) { x + y } }; let addTwo = newAdder(2); addTwo(2);", "4"},
+		{"let f = fn() { }; f()", "null"},
+		{"if (10 > 1) { if (10 > 1) { return 10; } return 1; }", "10"},
+		{"let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1) }; fact(25)", "15511210043330985984000000"},
+		{"fn(x, y) { x + y }", "fn(x, y) { (x + y) }"},
+	})
+}
+
+func TestArrays(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{"[1, 2, 3][1]", "2"},
+		{"[1, 2, 3][3]", "null"},
+		{"[1, 2, 3][-1]", "null"},
+		{"let a = [1, 2, 3]; a[0] + a[1] + a[2]", "6"},
+		{"len([1, 2])", "2"},
+		{"first([1, 2])", "1"},
+		{"last([1, 2])", "2"},
+		{"rest([1, 2, 3])", "[2, 3]"},
+		{"rest([])", "null"},
+		{"let a = [1]; let b = push(a, 2); [a, b]", "[[1], [1, 2]]"},
+	})
+}
+
+func TestAssignment(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; x = 2; x", "2"},
+		{"let x = 1; x += 2; x", "3"},
+		{"let x = 10; x -= 2; x *= 3; x /= 4; x", "6"},
+		{"let x = 1; let y = 1; x = y = 5; x + y", "10"},
+		{"let a = [1, 2]; a[1] = 5; a", "[1, 5]"},
+		{"let a = [1, 2]; a[0] += 5; a", "[6, 2]"},
+		{"let x = 1; let f = fn() { x = 2; }; f(); x", "2"},
+		{"let x = 1; if (true) { let x = 5; x = 6; } x", "1"},
+	})
+}
+
+func TestLoops(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn() { while (true) { return 1; } }; f()", "1"},
+		{"let i = 0; let sum = 0; while (i < 5) { i += 1; sum += i; } sum", "15"},
+		{"let fs = []; for (x in [1, 2]) { fs = push(fs, fn() { x }); } fs[0]() + fs[1]()", "3"},
+		{"let f = fn() { while (false) { return 1; } 2 }; f()", "2"},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x; } } 0 }; f()", "2"},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x < 3) { continue; } return x; } }; f()", "3"},
+		{"let f = fn() { for (x in [1, 2]) { break; return 1; } 2 }; f()", "2"},
+		{"let f = fn() { outer: for (x in [1, 2]) { for (y in [1, 2]) { continue outer; } return 0; } 3 }; f()", "3"},
+		{"let f = fn() { outer: while (true) { while (true) { break outer; } return 0; } 4 }; f()", "4"},
+		{"let f = fn() { while (true) { try { break; } finally { return 5; } } }; f()", "5"},
+	})
+}
+
+func TestTryCatch(t *testing.T) {
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{"try { throw 5; } catch (e) { e }", "5"},
+		{"try { 1 / 0; } catch (e) { e }", "ERROR: division by zero"},
+		{"try { len(1); } catch (e) { e }", "ERROR: argument to `len` not supported, got INTEGER"},
+		{"try { 1 } finally { 2 }", "1"},
+		{"try { throw 1; } catch (e) { e + 1 } finally { 10 }", "2"},
+		{"let f = fn() { try { return 1; } finally { return 2; } }; f()", "2"},
+		{"let f = fn() { try { throw 1; } finally { return 3; } }; f()", "3"},
+		{"let f = fn() { throw 7; }; try { f(); } catch (e) { e + 1 }", "8"},
+		{"try { try { throw 1; } catch (e) { throw e + 1; } } catch (e) { e }", "2"},
+		{`try { 1 / 0; } catch (e) { e["message"] }`, "division by zero"},
+		{`try { len(1); } catch (e) { e["message"] }`, "argument to `len` not supported, got INTEGER"},
+		{`let f = fn() { 1 / 0 }; try { f(); } catch (e) { e["stack"] }`, "ERROR: division by zero\n\nf(...)\n\t<input>:1:18\nmain()\n\t<input>:1:32\n"},
+	})
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) { true + false; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "undefined variable foobar"},
+		{"x = 1", "assignment to undefined variable x"},
+		{"1 / 0", "division by zero"},
+		{"fn(x) { x }()", "wrong number of arguments: want=1, got=0"},
+		{"len(1, 2)", "wrong number of arguments: want=1, got=2"},
+		{"5()", "not a function: INTEGER"},
+		{"let a = [1]; a[1] = 2", "index out of range: 1 with length 1"},
+		{`let a = [1]; a["k"] = 2`, "index is not an integer: STRING"},
+		{`let h = 5; h["k"] = 2`, "index assignment not supported: INTEGER"},
+		{`try { 1 / 0; } catch (e) { e["message"] = "x" }`, "index assignment not supported: ERROR"},
+		{"for (x in 5) { }", "cannot iterate over INTEGER"},
+		{"throw 42", "42"},
+		{"try { 1 / 0; } catch (e) { e[0] }", "index of an error is not a string: INTEGER"},
+		{`try { 1 / 0; } catch (e) { e["line"] }`, "an error has no field line"},
+		{"let f = fn() { f() }; f()", "stack overflow: more than 10000 nested calls"},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEval(t, tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q did not fail", tt.input)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("the error of %q is not %q. got = %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	input := `let divide = fn(a, b) {
+	a / b
+};
+let half = fn(x) { divide(x, 0) };
+half(4);`
+
+	err, ok := testEval(t, input).(*object.Error)
+	if !ok {
+		t.Fatalf("the program did not fail")
+	}
+
+	expected := `ERROR: division by zero
+
+divide(...)
+	<input>:2:4
+half(...)
+	<input>:4:26
+main()
+	<input>:5:5
+`
+	if err.StackTrace() != expected {
+		t.Errorf("the stack trace is not\n%s\ngot =\n%s", expected, err.StackTrace())
+	}
+}
+
+func TestBuiltinErrorPosition(t *testing.T) {
+	err, ok := testEval(t, "let f = fn(x) { first(x) };\nf(1);").(*object.Error)
+	if !ok {
+		t.Fatalf("the program did not fail")
+	}
+
+	trace := err.Trace()
+	if len(trace) != 2 || trace[0].Function != "f" || trace[0].String() != "<input>:1:22" {
+		t.Errorf("the builtin error is not raised at its call in f. got = %+v", trace)
+	}
+}
+
+func TestPersistentEnvironment(t *testing.T) {
+	env := object.NewEnvironment()
+	env.SetFile("rules.mk")
+
+	for _, input := range []string{"let x = 5;", "let f = fn() { x * 2 };"} {
+		program := parser.NewParser(lexer.NewLexer(input)).ParseProgram()
+		if _, err := EvalProgram(program, env, false); err != nil {
+			t.Fatalf("%q failed: %s", input, err.Message)
+		}
+	}
+
+	program := parser.NewParser(lexer.NewLexer("f() + y")).ParseProgram()
+	_, err := EvalProgram(program, env, false)
+	if err == nil || !strings.HasSuffix(err.StackTrace(), "main()\n\trules.mk:1:7\n") {
+		t.Errorf("the error does not point into rules.mk. got = %v", err)
+	}
+	if value, _ := EvalProgram(parser.NewParser(lexer.NewLexer("f()")).ParseProgram(), env, false); value.Inspect() != "10" {
+		t.Errorf("f() is not 10. got = %v", value)
+	}
+}
+
+func TestEvalOptimized(t *testing.T) {
+	for _, input := range []string{"60 * 60 * 24", "if (false) { 1 } else { 86400 }"} {
+		program := parser.NewParser(lexer.NewLexer(input)).ParseProgram()
+		value, err := EvalProgram(program, object.NewEnvironment(), true)
+		if err != nil || value.Inspect() != "86400" {
+			t.Errorf("the optimized %q is not 86400. got = %v, %v", input, value, err)
+		}
+	}
+
+	// Division by zero still fails when optimized
+	program := parser.NewParser(lexer.NewLexer("1 / 0")).ParseProgram()
+	if _, err := EvalProgram(program, object.NewEnvironment(), true); err == nil || err.Message != "division by zero" {
+		t.Errorf("the optimized 1 / 0 did not fail. got = %v", err)
+	}
+
+	// The optimizer does not change the value of a program, nor its error
+	for _, input := range []string{
+		"let f = fn() { 5; if (false) { 1 } }; f()",
+		"5; if (false) { 1 }",
+		"if (false) { 1 } 5",
+		"let x = 2; -(-x)",
+		`let x = "a"; -(-x)`,
+		"-(-(1 / 0 * 2.5))",
+	} {
+		results := []string{}
+		for _, optimize := range []bool{false, true} {
+			program := parser.NewParser(lexer.NewLexer(input)).ParseProgram()
+			value, err := EvalProgram(program, object.NewEnvironment(), optimize)
+			if err != nil {
+				results = append(results, err.Inspect())
+			} else {
+				results = append(results, value.Inspect())
+			}
+		}
+		if results[0] != results[1] {
+			t.Errorf("the optimized %q is not %q. got = %q", input, results[0], results[1])
+		}
+	}
+}
+
+// A function to write files into a new directory, and return the directory
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// A function to run a file with a loader searching path, and return its value or its uncaught error
+func testEvalFile(t *testing.T, file string, path ...string) object.Object {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parser.NewParser(lexer.NewLexer(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser has errors for %s: %v", file, p.Errors())
+	}
+
+	env := object.NewEnvironment()
+	env.SetFile(file)
+	env.SetImporter(NewLoader(path...))
+	result, evalErr := EvalProgram(program, env, false)
+	if evalErr != nil {
+		return evalErr
+	}
+	return result
+}
+
+func TestModules(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.mk": `let util = import "lib/util";
+let again = import "lib/util.mk";
+[util["double"](util["x"]), util == again, util["counter"][0], (import "shared")["name"]]`,
+		// The imports of a module are relative to the module
+		"lib/util.mk": `let helper = import "helper";
+export let x = helper["base"] + 1;
+export let double = fn(n) { n * 2 };
+// The top level runs once, however many times the module is imported
+export let counter = [0];
+counter[0] += 1;`,
+		"lib/helper.mk":    `export let base = 20;`,
+		"vendor/shared.mk": `export let name = "shared";`,
+		"private.mk":       `let util = import "lib/util"; util["helper"]`,
+		"missing.mk":       `import "nope"`,
+		"a.mk":             `import "b"`,
+		"b.mk":             `import "c"`,
+		"c.mk":             `import "a"`,
+		"broken.mk":        `let f = fn() { 1 / 0 }; import "lib/fails"`,
+		"lib/fails.mk":     "let x = 1;\nexport let y = x / 0;",
+		"syntax.mk":        `import "lib/syntax"`,
+		"lib/syntax.mk":    `let = 1;`,
+	})
+	vendor := filepath.Join(dir, "vendor")
+
+	value := testEvalFile(t, filepath.Join(dir, "main.mk"), vendor)
+	if value.Inspect() != "[42, true, 1, shared]" {
+		t.Errorf("main.mk is not [42, true, 1, shared]. got = %s", value.Inspect())
+	}
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"private.mk", "helper is not exported by lib/util.mk"},
+		{"missing.mk", "cannot find module nope.mk"},
+		// Without the search path
+		{"main.mk", "cannot find module shared.mk"},
+		{"a.mk", "import cycle: a.mk -> b.mk -> c.mk -> a.mk"},
+		{"syntax.mk", "lib/syntax.mk:1:5: Expect the next token to be VAR, got = instead"},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEvalFile(t, filepath.Join(dir, tt.file)).(*object.Error)
+		if !ok {
+			t.Errorf("%s did not fail", tt.file)
+			continue
+		}
+		message := strings.ReplaceAll(err.Message, dir+string(filepath.Separator), "")
+		if message != tt.expected {
+			t.Errorf("the error of %s is not %q. got = %q", tt.file, tt.expected, message)
+		}
+	}
+
+	// An error at the top level of a module is traced through the import
+	err, ok := testEvalFile(t, filepath.Join(dir, "broken.mk")).(*object.Error)
+	if !ok {
+		t.Fatalf("broken.mk did not fail")
+	}
+	expected := `ERROR: division by zero
+
+<module>
+	lib/fails.mk:2:18
+main()
+	broken.mk:1:25
+`
+	if trace := strings.ReplaceAll(err.StackTrace(), dir+string(filepath.Separator), ""); trace != expected {
+		t.Errorf("the stack trace is not\n%s\ngot =\n%s", expected, trace)
+	}
+}
+
+func TestNativeModules(t *testing.T) {
+	RegisterModule("native", map[string]object.Object{
+		"double": &object.Builtin{Name: "double", Fn: func(args ...object.Object) object.Object {
+			return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+		}},
+		"TEN": &object.Integer{Value: 10},
+	})
+	// A module can only be registered once, forget it for the next run of the test
+	t.Cleanup(func() { delete(nativeModules, "native") })
+
+	testInspect(t, []struct {
+		input    string
+		expected string
+	}{
+		{`let native = import "native"; native["double"](native["TEN"])`, "20"},
+		{`(import "native") == (import "native")`, "true"},
+		{`(import "native")["triple"]`, "ERROR: triple is not exported by native"},
+	})
+}
+
+func TestBuiltinNames(t *testing.T) {
+	names := strings.Join(BuiltinNames(), ",")
+	if names != "first,last,len,push,puts,rest" {
+		t.Errorf("the builtins are not first, last, len, push, puts and rest. got = %s", names)
+	}
+}
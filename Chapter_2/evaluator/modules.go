@@ -0,0 +1,196 @@
+package evaluator
+
+// Modules
+//
+// import "path" evaluates the file of a module once, in an environment of its own,
+// and gives a Module whose exported top-level bindings the importer can read
+// The path is looked for next to the importing file, then in the directories of the search path,
+// with the Extension added if it has none
+// A path that names a module written in Go, e.g. "strings", gives that module instead
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/object"
+	"Chapter_2/optimizer"
+	"Chapter_2/parser"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The extension of Monkey files, added to the imported paths without one
+const Extension = ".mk"
+
+// The modules written in Go, by the path they are imported with
+var nativeModules = map[string]*object.Module{}
+
+// A function to add a module written in Go, which programs import by its name, e.g. import "strings"
+// The libraries of the stdlib directory register theirs when they are imported, e.g.
+//
+//	import _ "Chapter_2/stdlib/strings"
+//
+// exports are the values of the module by name
+// It panics if the name is taken, so that a library cannot replace another by accident
+func RegisterModule(name string, exports map[string]object.Object) {
+	if _, ok := nativeModules[name]; ok {
+		panic("evaluator: module " + name + " registered twice")
+	}
+
+	env := object.NewEnvironment()
+	names := []string{}
+	for export, value := range exports {
+		env.Set(export, value)
+		names = append(names, export)
+	}
+	sort.Strings(names)
+	nativeModules[name] = &object.Module{Name: name, Env: env, Exports: names}
+}
+
+// A Loader finds, evaluates and caches the modules of a program
+// It is the object.Importer EvalProgram gives to an environment without one
+type Loader struct {
+	Path     []string                  // the directories searched after the one of the importing file
+	Optimize bool                      // run the optimizer on the modules before evaluating them
+	modules  map[string]*object.Module // the modules evaluated so far, by absolute file
+	loading  []loadingFile             // the files being imported, outermost first
+}
+
+// A file being imported, or the file that started the imports
+type loadingFile struct {
+	key  string // the absolute file, to compare files
+	name string // the file as it was found, to report cycles
+}
+
+// Create a loader that searches the directories of path after the one of the importing file
+func NewLoader(path ...string) *Loader {
+	return &Loader{Path: path, modules: map[string]*object.Module{}}
+}
+
+// A function to import the module of an import expression
+// A module is evaluated the first time it is imported, the next imports give the same module
+func (l *Loader) Import(node *ast.ImportExpression, env *object.Environment) object.Object {
+	if module, ok := nativeModules[node.Path.Value]; ok {
+		return module
+	}
+
+	file, err := l.find(node.Path.Value, env.File())
+	if err != nil {
+		return newError(node, env, "%s", err)
+	}
+
+	key := absolute(file)
+	if module, ok := l.modules[key]; ok {
+		return module
+	}
+
+	// The file that started the imports is part of the cycles too
+	if len(l.loading) == 0 && env.File() != "" {
+		l.loading = append(l.loading, loadingFile{key: absolute(env.File()), name: env.File()})
+		defer func() { l.loading = l.loading[:0] }()
+	}
+
+	for i, loading := range l.loading {
+		if loading.key == key {
+			chain := []string{}
+			for _, loading := range l.loading[i:] {
+				chain = append(chain, loading.name)
+			}
+			return newError(node, env, "import cycle: %s -> %s", strings.Join(chain, " -> "), file)
+		}
+	}
+
+	l.loading = append(l.loading, loadingFile{key: key, name: file})
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	result := l.load(node, file, env)
+	if module, ok := result.(*object.Module); ok {
+		l.modules[key] = module
+	}
+	return result
+}
+
+// A function to return the file of an imported path
+func (l *Loader) find(path string, from string) (string, error) {
+	if filepath.Ext(path) == "" {
+		path += Extension
+	}
+
+	dirs := []string{""}
+	if !filepath.IsAbs(path) {
+		// The directory of "" or "<stdin>" is the current directory
+		dirs = append([]string{filepath.Dir(from)}, l.Path...)
+	}
+
+	for _, dir := range dirs {
+		file := filepath.Join(dir, path)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot find module %s", path)
+}
+
+// A function to parse and evaluate the file of a module
+// The top level of the module runs in a frame of its own, so its errors have a stack trace through the import
+func (l *Loader) load(node *ast.ImportExpression, file string, env *object.Environment) object.Object {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return newError(node, env, "%s", err)
+	}
+
+	p := parser.NewParser(lexer.NewLexer(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return newError(node, env, "%s:%s", file, p.Errors()[0])
+	}
+	if l.Optimize {
+		program = optimizer.Optimize(program).(*ast.Program)
+	}
+
+	caller := env.Frame()
+	frame := &object.Frame{
+		Function: object.ModuleFrame,
+		File:     env.File(),
+		Line:     node.Token.Line,
+		Column:   node.Token.Column,
+		Caller:   caller,
+		Depth:    1,
+	}
+	if caller != nil {
+		frame.Depth = caller.Depth + 1
+	}
+
+	moduleEnv := object.NewEnvironment()
+	moduleEnv.SetFile(file)
+	moduleEnv.SetFrame(frame)
+	moduleEnv.SetImporter(l)
+
+	if result := Eval(program, moduleEnv); isSignal(result) {
+		return result
+	}
+
+	return &object.Module{Name: file, Env: moduleEnv, Exports: exports(program)}
+}
+
+// A function to return the names a program exports, in source order
+func exports(program *ast.Program) []string {
+	names := []string{}
+	for _, statement := range program.Statements {
+		if export, ok := statement.(*ast.ExportStatement); ok {
+			names = append(names, export.Statement.Variable.Literal)
+		}
+	}
+	return names
+}
+
+// A function to return the absolute file of a file, to tell whether two files are the same
+func absolute(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
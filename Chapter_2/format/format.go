@@ -0,0 +1,429 @@
+package format
+
+// A canonical pretty-printer for Monkey source code
+//
+// The output is indented with tabs, has one statement per line,
+// only the parentheses that the parser's precedence table requires,
+// and keeps the comments of the program
+// Formatting formatted code gives the same code back
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/parser"
+	"Chapter_2/token"
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// A function to parse and format a whole source file
+// It returns the parse errors instead if the source does not parse
+func Source(source string) (string, error) {
+	p := parser.NewParser(lexer.NewLexer(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return "", errors.New(strings.Join(p.Errors(), "\n"))
+	}
+	return Program(program), nil
+}
+
+// A function to format a program with its comments
+func Program(program *ast.Program) string {
+	p := &printer{comments: program.Comments}
+	p.statements(program.Statements)
+	// The comments after the last statement
+	p.flushComments(0)
+	return p.out.String()
+}
+
+// A function to format a single node without comments
+// Statements are followed by a newline, expressions are not
+func Node(node ast.Node) string {
+	p := &printer{}
+	switch node := node.(type) {
+	case *ast.Program:
+		return Program(node)
+	case ast.Statement:
+		p.statements([]ast.Statement{node})
+	case ast.Expression:
+		p.out.WriteString(p.expression(node))
+	}
+	return p.out.String()
+}
+
+// A printer contains
+type printer struct {
+	out      bytes.Buffer   // the formatted code so far
+	indent   int            // the current indentation level
+	comments []*ast.Comment // the comments not printed yet, in source order
+	lastLine int            // the last source line printed at the current level, 0 at the start of a block
+	limit    int            // the line of the closing bracket of the current block or array, 0 if there is none
+}
+
+// A function to print a list of statements, one per line
+func (p *printer) statements(statements []ast.Statement) {
+	for _, s := range statements {
+		startLine, endLine := lineRange(s)
+
+		// The comments before the statement go on their own lines
+		p.flushComments(startLine)
+
+		p.blankLine(startLine)
+		p.writeIndent()
+		p.statement(s)
+		p.trailingComment(startLine, endLine)
+
+		p.out.WriteString("\n")
+		if endLine > 0 {
+			p.lastLine = endLine
+		}
+	}
+}
+
+// A function to print the comments that start before a line
+// Every comment is printed if the line is 0
+func (p *printer) flushComments(line int) {
+	for len(p.comments) > 0 {
+		comment := p.comments[0]
+		if line > 0 && comment.Token.Line >= line {
+			return
+		}
+		p.blankLine(comment.Token.Line)
+		p.writeIndent()
+		p.out.WriteString(comment.Token.Literal + "\n")
+		p.lastLine = comment.Token.Line
+		p.comments = p.comments[1:]
+	}
+}
+
+// A function to print a comment left on the lines of a node at the end of the line the node ends on
+// The comments inside the blocks and arrays of the node are printed by now,
+// so the comment is one after the last node that ends on its line, e.g. after the closing brace of an if
+func (p *printer) trailingComment(startLine int, endLine int) {
+	if len(p.comments) == 0 || startLine == 0 {
+		return
+	}
+	comment := p.comments[0]
+	// A comment on the line of the closing bracket around the node comes after the bracket
+	if p.limit > 0 && comment.Token.Line >= p.limit {
+		return
+	}
+	if comment.Token.Line >= startLine && comment.Token.Line <= endLine {
+		p.out.WriteString(" " + comment.Token.Literal)
+		p.comments = p.comments[1:]
+	}
+}
+
+// A function to report whether the next comment is on the lines from start up to, but not including, end
+func (p *printer) commentBetween(start int, end int) bool {
+	if len(p.comments) == 0 {
+		return false
+	}
+	line := p.comments[0].Token.Line
+	return line >= start && line < end
+}
+
+// A function to keep one blank line where the source had one or more
+func (p *printer) blankLine(line int) {
+	if p.lastLine > 0 && line > p.lastLine+1 {
+		p.out.WriteString("\n")
+	}
+}
+
+func (p *printer) writeIndent() {
+	p.out.WriteString(strings.Repeat("\t", p.indent))
+}
+
+// A function to print a statement without its indentation and trailing newline
+func (p *printer) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		p.out.WriteString("let " + s.Variable.Literal + " = " + p.expression(s.Expression) + ";")
+
+	case *ast.ExportStatement:
+		p.out.WriteString("export ")
+		p.statement(s.Statement)
+
+	case *ast.ReturnStatement:
+		if s.ReturnValue == nil {
+			p.out.WriteString("return;")
+		} else {
+			p.out.WriteString("return " + p.expression(s.ReturnValue) + ";")
+		}
+
+	case *ast.ExpressionStatement:
+		// An if ends with a block like the other compound statements
+		if _, ok := s.Expression.(*ast.IfExpression); ok {
+			p.out.WriteString(p.expression(s.Expression))
+		} else {
+			p.out.WriteString(p.expression(s.Expression) + ";")
+		}
+
+	case *ast.ThrowStatement:
+		p.out.WriteString("throw " + p.expression(s.Value) + ";")
+
+	case *ast.BlockStatement:
+		p.block(s)
+
+	case *ast.TryStatement:
+		p.out.WriteString("try ")
+		p.block(s.Block)
+		if s.Catch != nil {
+			p.out.WriteString(" catch (" + s.CatchParam.Literal + ") ")
+			p.block(s.Catch)
+		}
+		if s.Finally != nil {
+			p.out.WriteString(" finally ")
+			p.block(s.Finally)
+		}
+
+	case *ast.WhileStatement:
+		p.label(s.Label)
+		p.out.WriteString("while (" + p.expression(s.Condition) + ") ")
+		p.block(s.Body)
+
+	case *ast.ForStatement:
+		p.label(s.Label)
+		p.out.WriteString("for (" + s.Variable.Literal + " in " + p.expression(s.Iterable) + ") ")
+		p.block(s.Body)
+
+	case *ast.BreakStatement:
+		p.out.WriteString("break" + labelSuffix(s.Label) + ";")
+
+	case *ast.ContinueStatement:
+		p.out.WriteString("continue" + labelSuffix(s.Label) + ";")
+	}
+}
+
+// A function to print a block with its statements indented one level deeper
+func (p *printer) block(block *ast.BlockStatement) {
+	if len(block.Statements) == 0 && !p.commentBetween(ast.TokenOf(block).Line, block.End.Line) {
+		p.out.WriteString("{}")
+		return
+	}
+
+	p.out.WriteString("{\n")
+
+	// The first statement of a block never gets a blank line before it
+	lastLine, limit := p.lastLine, p.limit
+	p.lastLine = 0
+	if block.End.Line > 0 {
+		p.limit = block.End.Line
+	}
+	p.indent += 1
+	p.statements(block.Statements)
+	// The comments after the last statement stay before the closing brace
+	if block.End.Line > 0 {
+		p.flushComments(block.End.Line)
+	}
+	p.indent -= 1
+	p.lastLine, p.limit = lastLine, limit
+
+	p.writeIndent()
+	p.out.WriteString("}")
+}
+
+// A function to format a block inside an expression, e.g. the body of a function literal
+// The block is indented from the current level like a block of a statement
+func (p *printer) blockString(block *ast.BlockStatement) string {
+	inner := &printer{indent: p.indent, comments: p.comments, lastLine: p.lastLine, limit: p.limit}
+	inner.block(block)
+	// The comments inside the block are printed by now
+	p.comments = inner.comments
+	return inner.out.String()
+}
+
+func (p *printer) label(label *ast.Variable) {
+	if label != nil {
+		p.out.WriteString(label.Literal + ": ")
+	}
+}
+
+func labelSuffix(label *ast.Variable) string {
+	if label == nil {
+		return ""
+	}
+	return " " + label.Literal
+}
+
+// The precedence of expressions that never need parentheses
+const atom = parser.INDEX + 1
+
+// A function to return the precedence an expression was parsed with
+func precedence(expression ast.Expression) int {
+	switch expression := expression.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(token.TokenType(expression.Operator))
+	case *ast.AssignExpression:
+		return parser.ASSIGNMENT
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.IndexExpression:
+		return parser.INDEX
+	case *ast.CallExpression:
+		return parser.CALL
+	default:
+		return atom
+	}
+}
+
+// A function to format an expression with as few parentheses as possible
+func (p *printer) expression(expression ast.Expression) string {
+	switch expression := expression.(type) {
+	case nil:
+		return ""
+
+	case *ast.Variable:
+		return expression.Literal
+
+	case *ast.IntegerLiteral, *ast.FloatLiteral:
+		return expression.String()
+
+	case *ast.PrefixExpression:
+		right := p.operand(expression.Right, precedence(expression.Right) < parser.PREFIX)
+		// Keep "- -x" from reading as a decrement
+		if strings.HasPrefix(right, expression.Operator) && expression.Operator == "-" {
+			return expression.Operator + " " + right
+		}
+		return expression.Operator + right
+
+	case *ast.InfixExpression:
+		// Infix operators are left-associative:
+		// "a - (b - c)" needs its parentheses, "(a - b) - c" does not
+		own := precedence(expression)
+		left := p.operand(expression.LeftValue, precedence(expression.LeftValue) < own)
+		right := p.operand(expression.RightValue, precedence(expression.RightValue) <= own)
+		return left + " " + expression.Operator + " " + right
+
+	case *ast.AssignExpression:
+		// Assignment is right-associative: "a = b = c" needs no parentheses
+		target := p.operand(expression.Target, precedence(expression.Target) < parser.INDEX)
+		value := p.operand(expression.Value, precedence(expression.Value) < parser.ASSIGNMENT)
+		return target + " " + expression.Operator + " " + value
+
+	case *ast.IndexExpression:
+		// Calls and indexes chain from left to right: "f(x)[0]" and "xs[0](x)" need no parentheses
+		left := p.operand(expression.Left, precedence(expression.Left) < parser.CALL)
+		return left + "[" + p.expression(expression.Index) + "]"
+
+	case *ast.CallExpression:
+		function := p.operand(expression.Function, precedence(expression.Function) < parser.CALL)
+		arguments := []string{}
+		for _, argument := range expression.Arguments {
+			arguments = append(arguments, p.expression(argument))
+		}
+		return function + "(" + strings.Join(arguments, ", ") + ")"
+
+	case *ast.ArrayLiteral:
+		if p.commentBetween(expression.Token.Line, expression.End.Line) {
+			return p.arrayLines(expression)
+		}
+		elements := []string{}
+		for _, element := range expression.Elements {
+			elements = append(elements, p.expression(element))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+
+	case *ast.IfExpression:
+		formatted := "if (" + p.expression(expression.Condition) + ") " + p.blockString(expression.Consequence)
+		if expression.Alternative != nil {
+			formatted += " else " + p.blockString(expression.Alternative)
+		}
+		return formatted
+
+	case *ast.FunctionLiteral:
+		parameters := []string{}
+		for _, parameter := range expression.Parameters {
+			parameters = append(parameters, parameter.Literal)
+		}
+		return "fn(" + strings.Join(parameters, ", ") + ") " + p.blockString(expression.Body)
+
+	default:
+		return expression.String()
+	}
+}
+
+// A function to format an array literal with comments inside it
+// Every element goes on its own line, followed by the comment on its line
+func (p *printer) arrayLines(array *ast.ArrayLiteral) string {
+	inner := &printer{indent: p.indent + 1, comments: p.comments, limit: array.End.Line}
+	inner.out.WriteString("[\n")
+	for i, element := range array.Elements {
+		startLine, endLine := lineRange(element)
+		inner.flushComments(startLine)
+
+		inner.writeIndent()
+		inner.out.WriteString(inner.expression(element))
+		if i < len(array.Elements)-1 {
+			inner.out.WriteString(",")
+		}
+		inner.trailingComment(startLine, endLine)
+
+		inner.out.WriteString("\n")
+		if endLine > 0 {
+			inner.lastLine = endLine
+		}
+	}
+	// The comments after the last element stay before the closing bracket
+	inner.flushComments(array.End.Line)
+	inner.indent -= 1
+	inner.writeIndent()
+	inner.out.WriteString("]")
+
+	// The comments inside the array are printed by now
+	p.comments = inner.comments
+	return inner.out.String()
+}
+
+// A function to format an operand, in parentheses if needed
+func (p *printer) operand(expression ast.Expression, parenthesize bool) string {
+	if parenthesize {
+		return "(" + p.expression(expression) + ")"
+	}
+	return p.expression(expression)
+}
+
+// A function to return the first and last source lines of a node
+// Both are 0 if the node has no positions
+//
+// A block or an array literal ends at its closing bracket
+// When the position of a brace is unknown, e.g. in a tree built by hand,
+// a non-empty block is taken to end one line after its last statement,
+// which is where the formatter puts the brace
+func lineRange(n ast.Node) (int, int) {
+	startLine, endLine := 0, 0
+	ast.Inspect(n, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		line := ast.TokenOf(node).Line
+		if line > 0 && (startLine == 0 || line < startLine) {
+			startLine = line
+		}
+		if line > endLine {
+			endLine = line
+		}
+
+		// Blocks may be nested in expressions too, e.g. in function literals
+		switch node := node.(type) {
+		case *ast.BlockStatement:
+			end := node.End.Line
+			if end == 0 && len(node.Statements) > 0 {
+				if _, lastStatementEnd := lineRange(node.Statements[len(node.Statements)-1]); lastStatementEnd > 0 {
+					end = lastStatementEnd + 1
+				}
+			}
+			if end > endLine {
+				endLine = end
+			}
+		case *ast.ArrayLiteral:
+			if node.End.Line > endLine {
+				endLine = node.End.Line
+			}
+		}
+		return true
+	})
+
+	return startLine, endLine
+}
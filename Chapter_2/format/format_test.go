@@ -0,0 +1,158 @@
+package format
+
+import (
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=5", "let x = 5;\n"},
+		{"1+2*3;", "1 + 2 * 3;\n"},
+		{"(1+2)*3;", "(1 + 2) * 3;\n"},
+		{"1-(2-3);", "1 - (2 - 3);\n"},
+		{"(1-2)-3;", "1 - 2 - 3;\n"},
+		{"a<b==c>d;", "a < b == c > d;\n"},
+		{"a==(b==c);", "a == (b == c);\n"},
+		{"-(a+b);", "-(a + b);\n"},
+		{"-(-a);", "- -a;\n"},
+		{"!-a;", "!-a;\n"},
+		{"(a+b)[i*2];", "(a + b)[i * 2];\n"},
+		{"a=b=c+1;", "a = b = c + 1;\n"},
+		{"arr[i]+=-1;", "arr[i] += -1;\n"},
+		{"let r=2.5e3*-0.5;", "let r = 2.5e3 * -0.5;\n"},
+		{"return;", "return;\n"},
+		{"let add=fn(a,b){a+b};", "let add = fn(a, b) {\n\ta + b;\n};\n"},
+		{"fn(){}(1)*f(x,-y)[0];", "fn() {}(1) * f(x, -y)[0];\n"},
+		{"(a+b)(c);", "(a + b)(c);\n"},
+		{"[1,2*3,[]][0];", "[1, 2 * 3, []][0];\n"},
+		{"if(x<y){x}else{!true}", "if (x < y) {\n\tx;\n} else {\n\t!true;\n}\n"},
+		{"let m=if(a){b};", "let m = if (a) {\n\tb;\n};\n"},
+		{"return x;", "return x;\n"},
+		{"throw x", "throw x;\n"},
+		{`let s="a\tb"+"é"`, "let s = \"a\\tb\" + \"é\";\n"},
+		{`export let u=import "lib/util";`, "export let u = import \"lib/util\";\n"},
+		{`(import "m")["f"](1);`, "import \"m\"[\"f\"](1);\n"},
+		{
+			"try{throw 1;}catch(e){e;}finally{}",
+			"try {\n\tthrow 1;\n} catch (e) {\n\te;\n} finally {}\n",
+		},
+		{
+			"outer:for(x in xs){while(x<10){x+=1;break outer;}continue;}",
+			"outer: for (x in xs) {\n\twhile (x < 10) {\n\t\tx += 1;\n\t\tbreak outer;\n\t}\n\tcontinue;\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source(tt.input)
+		if err != nil {
+			t.Fatalf("Source(%q) returned an error: %s", tt.input, err)
+		}
+
+		if formatted != tt.expected {
+			t.Errorf("Source(%q) is not %q. got = %q", tt.input, tt.expected, formatted)
+		}
+
+		again, err := Source(formatted)
+		if err != nil {
+			t.Fatalf("Source(%q) returned an error: %s", formatted, err)
+		}
+
+		if again != formatted {
+			t.Errorf("formatting is not idempotent for %q. got = %q", formatted, again)
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	input := `// Header comment
+
+let x = 5;   // five
+let y = x *  2;
+
+
+// Loop over things
+while (x) {
+  // inside
+    x -= 1; // step
+  while (y) { y -= 1; }
+}
+x;
+let f = fn(a) {
+  // body
+  a; };
+let g = f; // alias
+if (x) { 1; } else {
+  2;
+} // after else
+let h = fn() {
+  g;
+  // before the brace
+};
+let xs = [
+  1, // one
+  2
+];
+// trailing
+`
+	expected := `// Header comment
+
+let x = 5; // five
+let y = x * 2;
+
+// Loop over things
+while (x) {
+	// inside
+	x -= 1; // step
+	while (y) {
+		y -= 1;
+	}
+}
+x;
+let f = fn(a) {
+	// body
+	a;
+};
+let g = f; // alias
+if (x) {
+	1;
+} else {
+	2;
+} // after else
+let h = fn() {
+	g;
+	// before the brace
+};
+let xs = [
+	1, // one
+	2
+];
+// trailing
+`
+
+	formatted, err := Source(input)
+	if err != nil {
+		t.Fatalf("Source() returned an error: %s", err)
+	}
+
+	if formatted != expected {
+		t.Errorf("Source() is not\n%s\ngot =\n%s", expected, formatted)
+	}
+
+	again, err := Source(formatted)
+	if err != nil {
+		t.Fatalf("Source() returned an error: %s", err)
+	}
+
+	if again != formatted {
+		t.Errorf("formatting is not idempotent. got =\n%s", again)
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	if _, err := Source("let = 5;"); err == nil {
+		t.Errorf("Source() did not return an error")
+	}
+}
@@ -5,6 +5,7 @@ package lexer
 import (
 	"Chapter_2/token"
 	"fmt"
+	"strings"
 )
 
 // A lexer contains
@@ -13,12 +14,19 @@ type Lexer struct {
 	curIndex  int    // The current index of that string
 	nextIndex int    // The next index of that string
 	curChar   byte   // The current char of that string
+	line      int    // The line of the current char
+	column    int    // The column of the current char
+
+	comments []token.Token // The comments skipped so far
+
+	operators []token.Token              // The registered symbol operators, longest first
+	words     map[string]token.TokenType // The registered word operators
 }
 
 // A function to create a new lexer
 func NewLexer(input string) *Lexer {
 	// Set the current input
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	// Read the current character
 	l.readChar()
 	return l
@@ -26,6 +34,12 @@ func NewLexer(input string) *Lexer {
 
 // A function to read the current character of a lexer and move on
 func (l *Lexer) readChar() {
+	// Move the position to the start of a new line after a newline
+	if l.curChar == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
 	// The nextIndex is 'out of bound'
 	if l.nextIndex >= len(l.input) {
 		// Set the current character to EOF
@@ -51,22 +65,32 @@ func (lexer *Lexer) PrintLexer() {
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 	l.skipWhiteSpace()
+	// Remember where the token starts
+	line, column := l.line, l.column
+
+	// Registered operators take priority over the built-in ones, see RegisterOperator
+	if tok, ok := l.readRegisteredOperator(); ok {
+		tok.Line, tok.Column = line, column
+		return tok
+	}
 	// Depending on the current character,
 	// decide how to read the token
 	switch l.curChar {
 	// Single-char string case
 	case '+':
-		tok = NewToken(token.PLUS, l.curChar)
+		tok = l.newOperatorAssignToken(token.PLUS, token.PLUSASSIGN)
 	case '-':
-		tok = NewToken(token.MINUS, l.curChar)
+		tok = l.newOperatorAssignToken(token.MINUS, token.MINUSASSIGN)
 	case '*':
-		tok = NewToken(token.MULT, l.curChar)
+		tok = l.newOperatorAssignToken(token.MULT, token.MULTASSIGN)
 	case '/':
-		tok = NewToken(token.DIV, l.curChar)
+		tok = l.newOperatorAssignToken(token.DIV, token.DIVASSIGN)
 	case ',':
 		tok = NewToken(token.COMMA, l.curChar)
 	case ';':
 		tok = NewToken(token.SEMICOLON, l.curChar)
+	case ':':
+		tok = NewToken(token.COLON, l.curChar)
 	case '[':
 		tok = NewToken(token.SLBRACKET, l.curChar)
 	case ']':
@@ -83,6 +107,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = NewToken(token.LT, l.curChar)
 	case '>':
 		tok = NewToken(token.GT, l.curChar)
+	case '"':
+		tok = l.readString()
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -120,27 +146,123 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Literal = l.readWord()
 			// Decide if the token is variable or a keyword
 			tok.Type = token.LookUpKeyword(tok.Literal)
+			// or a registered word operator
+			if wordType, ok := l.words[tok.Literal]; ok && tok.Type == token.VARIABLE {
+				tok.Type = wordType
+			}
+			tok.Line, tok.Column = line, column
 			return tok
 		} else if isDigit(l.curChar) { // If it's a number
-			// Read the whole number
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
-
+			// Read the whole number, an integer or a float
+			tok.Literal, tok.Type = l.readNumber()
+			tok.Line, tok.Column = line, column
 			return tok
 		} else { // If's something really weird
 			tok = NewToken(token.ILLEGAL, l.curChar)
 		}
 	}
+	tok.Line, tok.Column = line, column
 	// Move on to the next token
 	l.readChar()
 	return tok
 }
 
+// A function to skip white space and comments
+// The skipped comments are remembered, see Comments
 func (l *Lexer) skipWhiteSpace() {
-	// While the current character is any white space
-	for l.curChar == ' ' || l.curChar == '\t' || l.curChar == '\n' || l.curChar == '\r' {
+	for {
+		// While the current character is any white space
+		for l.curChar == ' ' || l.curChar == '\t' || l.curChar == '\n' || l.curChar == '\r' {
+			l.readChar()
+		}
+
+		// A comment starts with "//" and runs until the end of the line
+		// A shebang line, e.g. "#!/usr/bin/env monkey", is a comment too, at the start of the input
+		isComment := l.curChar == '/' && l.peekChar() == '/'
+		isShebang := l.curIndex == 0 && l.curChar == '#' && l.peekChar() == '!'
+		if !isComment && !isShebang {
+			return
+		}
+		l.readComment()
+	}
+}
+
+// A function to read a comment and remember it
+func (l *Lexer) readComment() {
+	comment := token.Token{Type: token.COMMENT, Line: l.line, Column: l.column}
+	startIndex := l.curIndex
+	for l.curChar != '\n' && l.curChar != 0 {
 		l.readChar()
 	}
+	comment.Literal = strings.TrimRight(l.input[startIndex:l.curIndex], "\r")
+	l.comments = append(l.comments, comment)
+}
+
+// A function to return the comments the lexer has skipped so far, in source order
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+// A function to teach the lexer an extra operator
+// A symbol operator (e.g. "|>" or "..") is matched before the built-in operators,
+// longest first, so "**" wins over "*"
+// A word operator (e.g. "mod") is lexed like a keyword; keywords keep their own token type
+func (l *Lexer) RegisterOperator(literal string, tokenType token.TokenType) {
+	if literal == "" {
+		return
+	}
+
+	if isWord(literal) {
+		if l.words == nil {
+			l.words = make(map[string]token.TokenType)
+		}
+		l.words[literal] = tokenType
+		return
+	}
+
+	operator := token.Token{Type: tokenType, Literal: literal}
+	// Keep the operators sorted from the longest to the shortest
+	index := len(l.operators)
+	for i, registered := range l.operators {
+		if registered.Literal == literal {
+			l.operators[i] = operator
+			return
+		}
+		if len(registered.Literal) < len(literal) && index == len(l.operators) {
+			index = i
+		}
+	}
+	l.operators = append(l.operators, token.Token{})
+	copy(l.operators[index+1:], l.operators[index:])
+	l.operators[index] = operator
+}
+
+// A function to read a registered symbol operator at the current character
+func (l *Lexer) readRegisteredOperator() (token.Token, bool) {
+	// Past the end of the input
+	if l.curIndex >= len(l.input) {
+		return token.Token{}, false
+	}
+
+	for _, operator := range l.operators {
+		if strings.HasPrefix(l.input[l.curIndex:], operator.Literal) {
+			for i := 0; i < len(operator.Literal); i++ {
+				l.readChar()
+			}
+			return operator, true
+		}
+	}
+	return token.Token{}, false
+}
+
+// A function to check if a literal is made of letters only
+func isWord(literal string) bool {
+	for i := 0; i < len(literal); i++ {
+		if !isLetter(literal[i]) {
+			return false
+		}
+	}
+	return true
 }
 
 // A function to create a new token
@@ -148,6 +270,18 @@ func NewToken(tokenType token.TokenType, tokenLiteral byte) token.Token {
 	return token.Token{Type: tokenType, Literal: string(tokenLiteral)}
 }
 
+// A function to create the token of an operator that can be followed by "="
+// e.g. "+" or "+="
+func (l *Lexer) newOperatorAssignToken(operatorType token.TokenType, assignType token.TokenType) token.Token {
+	// Check if the next char is "="
+	if l.peekChar() == '=' {
+		operator := l.curChar
+		l.readChar()
+		return token.Token{Type: assignType, Literal: string(operator) + string(l.curChar)}
+	}
+	return NewToken(operatorType, l.curChar)
+}
+
 func (l *Lexer) peekChar() byte {
 	if l.nextIndex >= len(l.input) {
 		return 0
@@ -168,14 +302,64 @@ func (l *Lexer) readWord() string {
 	return l.input[startIndex:l.curIndex]
 }
 
+// A function to read a string literal, the current character being its opening quote
+// The literal of the token is the raw text between the quotes, its escapes are decoded by the parser
+// A string without its closing quote on the same line is ILLEGAL, with the text read so far
+func (l *Lexer) readString() token.Token {
+	startIndex := l.curIndex + 1
+	for {
+		l.readChar()
+		switch l.curChar {
+		case '"':
+			return token.Token{Type: token.STRING, Literal: l.input[startIndex:l.curIndex]}
+		case '\\':
+			// Skip over the escaped character, so an escaped quote does not end the string
+			if l.peekChar() != '\n' && l.peekChar() != 0 {
+				l.readChar()
+			}
+		case '\n', 0:
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[startIndex-1 : l.curIndex]}
+		}
+	}
+}
+
 func isDigit(curChar byte) bool {
 	return '0' <= curChar && curChar <= '9'
 }
 
-func (l *Lexer) readNumber() string {
+// A function to read a number, e.g. 42, 3.14 or 6.02e23
+// It is a float if it has a fraction or an exponent, which need digits: 1. and 1e are an integer followed by something else
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	startIndex := l.curIndex
+	tokenType := token.TokenType(token.INT)
+	l.readDigits()
+
+	if l.curChar == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+
+	if l.curChar == 'e' || l.curChar == 'E' {
+		// The digits of the exponent come after its sign, if it has one
+		digitIndex := l.nextIndex
+		if sign := l.peekChar(); sign == '+' || sign == '-' {
+			digitIndex++
+		}
+		if digitIndex < len(l.input) && isDigit(l.input[digitIndex]) {
+			tokenType = token.FLOAT
+			for l.curIndex < digitIndex {
+				l.readChar()
+			}
+			l.readDigits()
+		}
+	}
+
+	return l.input[startIndex:l.curIndex], tokenType
+}
+
+func (l *Lexer) readDigits() {
 	for isDigit(l.curChar) {
 		l.readChar()
 	}
-	return l.input[startIndex:l.curIndex]
 }
@@ -22,6 +22,9 @@ return false;
 }
 10 == 10;
 10 != 9;
+try { throw x; } catch (e) {} finally {}
+outer: for (x in xs) { while (x) { break outer; continue; } }
+x += 1; x -= 2; x *= 3; x /= 4; xs[0] = 5;
 `
 
 	// The test should check as followed
@@ -113,6 +116,68 @@ return false;
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
 
+		{token.TRY, "try"},
+		{token.PLBRACKET, "{"},
+		{token.THROW, "throw"},
+		{token.VARIABLE, "x"},
+		{token.SEMICOLON, ";"},
+		{token.PRBRACKET, "}"},
+		{token.CATCH, "catch"},
+		{token.RLBRACKET, "("},
+		{token.VARIABLE, "e"},
+		{token.RRBRACKET, ")"},
+		{token.PLBRACKET, "{"},
+		{token.PRBRACKET, "}"},
+		{token.FINALLY, "finally"},
+		{token.PLBRACKET, "{"},
+		{token.PRBRACKET, "}"},
+
+		{token.VARIABLE, "outer"},
+		{token.COLON, ":"},
+		{token.FOR, "for"},
+		{token.RLBRACKET, "("},
+		{token.VARIABLE, "x"},
+		{token.IN, "in"},
+		{token.VARIABLE, "xs"},
+		{token.RRBRACKET, ")"},
+		{token.PLBRACKET, "{"},
+		{token.WHILE, "while"},
+		{token.RLBRACKET, "("},
+		{token.VARIABLE, "x"},
+		{token.RRBRACKET, ")"},
+		{token.PLBRACKET, "{"},
+		{token.BREAK, "break"},
+		{token.VARIABLE, "outer"},
+		{token.SEMICOLON, ";"},
+		{token.CONTINUE, "continue"},
+		{token.SEMICOLON, ";"},
+		{token.PRBRACKET, "}"},
+		{token.PRBRACKET, "}"},
+
+		{token.VARIABLE, "x"},
+		{token.PLUSASSIGN, "+="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.VARIABLE, "x"},
+		{token.MINUSASSIGN, "-="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.VARIABLE, "x"},
+		{token.MULTASSIGN, "*="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.VARIABLE, "x"},
+		{token.DIVASSIGN, "/="},
+		{token.INT, "4"},
+		{token.SEMICOLON, ";"},
+		{token.VARIABLE, "xs"},
+		{token.SLBRACKET, "["},
+		{token.INT, "0"},
+		{token.SRBRACKET, "]"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+
 		{token.EOF, ""},
 	}
 
@@ -130,3 +195,239 @@ return false;
 
 	}
 }
+
+func TestTokenPosition(t *testing.T) {
+	input := `let x = 5;
+  x == 10;
+`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"x", 2, 3},
+		{"==", 2, 5},
+		{"10", 2, 8},
+		{";", 2, 10},
+		{"", 3, 1},
+	}
+
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("Failed at [%d] - wrong literal, expected %q, got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("Failed at [%d] - wrong position, expected %d:%d, got %s", i, tt.expectedLine, tt.expectedColumn, tok.Position())
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading
+let x = 5; // trailing
+x / 2;
+//`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.VARIABLE, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.VARIABLE, "x"},
+		{token.DIV, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("Failed at [%d] - wrong token type, expected %q, got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("Failed at [%d] - wrong literal, expected %q, got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	expectedComments := []struct {
+		literal  string
+		position string
+	}{
+		{"// leading", "1:1"},
+		{"// trailing", "2:12"},
+		{"//", "4:1"},
+	}
+
+	comments := l.Comments()
+	if len(comments) != len(expectedComments) {
+		t.Fatalf("wrong number of comments, expected %d, got %d", len(expectedComments), len(comments))
+	}
+
+	for i, expected := range expectedComments {
+		if comments[i].Type != token.COMMENT || comments[i].Literal != expected.literal {
+			t.Errorf("Failed at [%d] - wrong comment, expected %q, got %q", i, expected.literal, comments[i].Literal)
+		}
+
+		if comments[i].Position() != expected.position {
+			t.Errorf("Failed at [%d] - wrong position, expected %s, got %s", i, expected.position, comments[i].Position())
+		}
+	}
+}
+
+func TestShebang(t *testing.T) {
+	l := NewLexer("#!/usr/bin/env monkey\nlet x = 1; #!")
+
+	expected := []token.TokenType{token.LET, token.VARIABLE, token.ASSIGN, token.INT, token.SEMICOLON, token.ILLEGAL, token.EXCLAMATION, token.EOF}
+	for i, expectedType := range expected {
+		if tok := l.NextToken(); tok.Type != expectedType {
+			t.Fatalf("Failed at [%d] - wrong token type, expected %q, got %q", i, expectedType, tok.Type)
+		}
+	}
+
+	comments := l.Comments()
+	if len(comments) != 1 || comments[0].Literal != "#!/usr/bin/env monkey" || comments[0].Position() != "1:1" {
+		t.Errorf("the shebang line is not a comment at 1:1. got = %+v", comments)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	input := `import "lib/util"; export let s = "say \"hi\"\n" + "";
+"héllo" "open`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.IMPORT, "import", 1},
+		{token.STRING, "lib/util", 8},
+		{token.SEMICOLON, ";", 18},
+		{token.EXPORT, "export", 20},
+		{token.LET, "let", 27},
+		{token.VARIABLE, "s", 31},
+		{token.ASSIGN, "=", 33},
+		{token.STRING, `say \"hi\"\n`, 35},
+		{token.PLUS, "+", 50},
+		{token.STRING, "", 52},
+		{token.SEMICOLON, ";", 54},
+		// The columns count bytes
+		{token.STRING, "héllo", 1},
+		// An unterminated string
+		{token.ILLEGAL, `"open`, 10},
+	}
+
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("Failed at [%d] - wrong token type, expected %q, got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("Failed at [%d] - wrong literal, expected %q, got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Column != tt.expectedColumn {
+			t.Fatalf("Failed at [%d] - wrong column, expected %d, got %d", i, tt.expectedColumn, tok.Column)
+		}
+	}
+}
+
+func TestRegisterOperator(t *testing.T) {
+	input := `a |> b..c ** d * e mod f in g`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.VARIABLE, "a"},
+		{"|>", "|>"},
+		{token.VARIABLE, "b"},
+		{"..", ".."},
+		{token.VARIABLE, "c"},
+		{"**", "**"},
+		{token.VARIABLE, "d"},
+		{token.MULT, "*"},
+		{token.VARIABLE, "e"},
+		{"MOD", "mod"},
+		{token.VARIABLE, "f"},
+		{token.IN, "in"},
+		{token.VARIABLE, "g"},
+		{token.EOF, ""},
+	}
+
+	l := NewLexer(input)
+	l.RegisterOperator("..", "..")
+	l.RegisterOperator("|>", "|>")
+	l.RegisterOperator("**", "**")
+	l.RegisterOperator("mod", "MOD")
+	// A keyword keeps its own token type
+	l.RegisterOperator("in", "INOP")
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("Failed at [%d] - wrong token type, expected %q, got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("Failed at [%d] - wrong literal, expected %q, got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	input := `3.14 42 6.02e23 1E-9 2e+3 1.e 5e x.5 [1.5]`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "3.14"},
+		{token.INT, "42"},
+		{token.FLOAT, "6.02e23"},
+		{token.FLOAT, "1E-9"},
+		{token.FLOAT, "2e+3"},
+		// A point or an e without digits is not part of the number
+		{token.INT, "1"},
+		{token.ILLEGAL, "."},
+		{token.VARIABLE, "e"},
+		{token.INT, "5"},
+		{token.VARIABLE, "e"},
+		{token.VARIABLE, "x"},
+		{token.ILLEGAL, "."},
+		{token.INT, "5"},
+		{token.SLBRACKET, "["},
+		{token.FLOAT, "1.5"},
+		{token.SRBRACKET, "]"},
+		{token.EOF, ""},
+	}
+
+	l := NewLexer(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("Failed at [%d] - wrong token type, expected %q, got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("Failed at [%d] - wrong literal, expected %q, got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package lint
+
+// The checks of the linter
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/token"
+	"strings"
+)
+
+// A function to report the let bindings and parameters that are never read
+// Names starting with "_" are meant to be unused, and exported names are read by the importers
+func (l *linter) unused(program *ast.Program) {
+	lets := []*ast.Variable{}
+	parameters := []*ast.Variable{}
+	// The variables that declare or overwrite a binding rather than read it
+	writes := map[*ast.Variable]bool{}
+	exported := map[*ast.Variable]bool{}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.ExportStatement:
+			exported[node.Statement.Variable] = true
+		case *ast.LetStatement:
+			lets = append(lets, node.Variable)
+			writes[node.Variable] = true
+		case *ast.FunctionLiteral:
+			parameters = append(parameters, node.Parameters...)
+			for _, parameter := range node.Parameters {
+				writes[parameter] = true
+			}
+		case *ast.ForStatement:
+			writes[node.Variable] = true
+		case *ast.TryStatement:
+			writes[node.CatchParam] = true
+		case *ast.AssignExpression:
+			if variable, ok := node.Target.(*ast.Variable); ok && node.Operator == "=" {
+				writes[variable] = true
+			}
+		}
+		return true
+	})
+
+	read := map[*ast.Variable]bool{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if variable, ok := node.(*ast.Variable); ok && !writes[variable] && variable.Binding != nil {
+			read[variable.Binding.Declaration] = true
+		}
+		return true
+	})
+
+	for _, variable := range lets {
+		// A name declared again in the same scope shares the binding of its first declaration
+		if variable.Binding == nil || variable.Binding.Declaration != variable {
+			continue
+		}
+		if !read[variable] && !exported[variable] && !strings.HasPrefix(variable.Literal, "_") {
+			l.report("unused-variable", variable.Token, "%s is declared but never used", variable.Literal)
+		}
+	}
+
+	for _, variable := range parameters {
+		if variable.Binding == nil {
+			continue
+		}
+		if !read[variable] && !strings.HasPrefix(variable.Literal, "_") {
+			l.report("unused-parameter", variable.Token, "parameter %s is never used", variable.Literal)
+		}
+	}
+}
+
+// A function to report the declarations of a scope that shadow a name of an enclosing scope
+// The declared variables, e.g. parameters, come before the lets of the statements
+// The nested scopes are checked with this scope as their enclosing scope
+func (l *linter) scope(statements []ast.Statement, outer []map[string]bool, declared ...*ast.Variable) {
+	for _, statement := range statements {
+		if export, ok := statement.(*ast.ExportStatement); ok {
+			statement = export.Statement
+		}
+		if let, ok := statement.(*ast.LetStatement); ok {
+			declared = append(declared, let.Variable)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, variable := range declared {
+		if names[variable.Literal] || strings.HasPrefix(variable.Literal, "_") {
+			names[variable.Literal] = true
+			continue
+		}
+		names[variable.Literal] = true
+
+		for _, enclosing := range outer {
+			if enclosing[variable.Literal] {
+				l.report("shadow", variable.Token, "%s shadows a declaration of an enclosing scope", variable.Literal)
+				break
+			}
+		}
+	}
+
+	// A new slice, so the sibling scopes do not share the nested ones
+	scopes := append(append([]map[string]bool{}, outer...), names)
+	for _, statement := range statements {
+		ast.Inspect(statement, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.FunctionLiteral:
+				l.scope(node.Body.Statements, scopes, node.Parameters...)
+				return false
+			case *ast.ForStatement:
+				ast.Inspect(node.Iterable, func(node ast.Node) bool {
+					if function, ok := node.(*ast.FunctionLiteral); ok {
+						l.scope(function.Body.Statements, scopes, function.Parameters...)
+						return false
+					}
+					return true
+				})
+				l.scope(node.Body.Statements, scopes, node.Variable)
+				return false
+			case *ast.TryStatement:
+				l.scope(node.Block.Statements, scopes)
+				if node.Catch != nil {
+					l.scope(node.Catch.Statements, scopes, node.CatchParam)
+				}
+				if node.Finally != nil {
+					l.scope(node.Finally.Statements, scopes)
+				}
+				return false
+			case *ast.BlockStatement:
+				l.scope(node.Statements, scopes)
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// The ast.Inspect function of the checks on single nodes
+func (l *linter) inspect(node ast.Node) bool {
+	switch node := node.(type) {
+	case *ast.Program:
+		l.unreachable(node.Statements)
+
+	case *ast.BlockStatement:
+		l.unreachable(node.Statements)
+
+	case *ast.InfixExpression:
+		switch node.Operator {
+		case "==", "!=", "<", ">":
+			if node.LeftValue.String() == node.RightValue.String() && isPure(node.LeftValue) {
+				l.report("self-comparison", node.Token, "%s is compared with itself", node.LeftValue.String())
+			}
+		}
+
+	case *ast.IfExpression:
+		if isConstant(node.Condition) {
+			l.report("constant-condition", node.Token, "the condition %s is constant", node.Condition.String())
+		}
+	}
+
+	return true
+}
+
+// A function to report the first statement after a return, throw, break or continue
+func (l *linter) unreachable(statements []ast.Statement) {
+	for i, statement := range statements[:max(len(statements)-1, 0)] {
+		var exit token.Token
+		switch statement := statement.(type) {
+		case *ast.ReturnStatement:
+			exit = statement.Token
+		case *ast.ThrowStatement:
+			exit = statement.Token
+		case *ast.BreakStatement:
+			exit = statement.Token
+		case *ast.ContinueStatement:
+			exit = statement.Token
+		default:
+			continue
+		}
+
+		l.report("unreachable", ast.TokenOf(statements[i+1]), "unreachable code after %s", exit.Literal)
+		return
+	}
+}
+
+// A function to report whether evaluating an expression has no side effects
+func isPure(expression ast.Expression) bool {
+	pure := true
+	ast.Inspect(expression, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.CallExpression, *ast.AssignExpression:
+			pure = false
+		}
+		return pure
+	})
+	return pure
+}
+
+// A function to report whether an expression only depends on literals
+func isConstant(expression ast.Expression) bool {
+	constant := true
+	ast.Inspect(expression, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.Variable, *ast.CallExpression:
+			constant = false
+		}
+		return constant
+	})
+	return constant
+}
@@ -0,0 +1,153 @@
+package lint
+
+// A linter for Monkey programs
+//
+// Every diagnostic comes from a check with an ID and a severity
+// A check is suppressed on a line by a comment on that line or on the line before it:
+//
+//	// lint:ignore unused-variable,shadow
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/parser"
+	"Chapter_2/resolver"
+	"Chapter_2/token"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The severity of a check
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Info    Severity = "info"
+)
+
+// A Check contains
+type Check struct {
+	ID       string
+	Severity Severity
+	Summary  string
+}
+
+// The checks of the linter
+var Checks = []Check{
+	{"scope", Error, "a name is undefined or used before its definition, or a parameter is declared twice"},
+	{"unused-variable", Warning, "a let binding is never used"},
+	{"unused-parameter", Info, "a function parameter is never used"},
+	{"shadow", Info, "a declaration shadows a name of an enclosing scope"},
+	{"unreachable", Warning, "a statement follows a return, throw, break or continue in the same block"},
+	{"self-comparison", Warning, "a value is compared with itself"},
+	{"constant-condition", Warning, "the condition of an if is always the same"},
+}
+
+// A Diagnostic contains
+type Diagnostic struct {
+	Check    string // the ID of the check that reported it
+	Severity Severity
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", d.Line, d.Column, d.Severity, d.Message, d.Check)
+}
+
+// A function to parse and lint a whole source file
+// It returns the parse errors instead if the source does not parse
+func Source(source string, builtins ...string) ([]Diagnostic, error) {
+	p := parser.NewParser(lexer.NewLexer(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return nil, errors.New(strings.Join(p.Errors(), "\n"))
+	}
+	return Program(program, builtins...), nil
+}
+
+// A function to lint a program, sorted by position
+// The builtins are the names the program may use without declaring them
+// The variables of the program are resolved as a side effect
+func Program(program *ast.Program, builtins ...string) []Diagnostic {
+	l := &linter{severities: map[string]Severity{}}
+	for _, check := range Checks {
+		l.severities[check.ID] = check.Severity
+	}
+
+	r := resolver.New(builtins...)
+	r.Resolve(program)
+	for _, msg := range r.Errors() {
+		l.resolverError(msg)
+	}
+
+	l.unused(program)
+	l.scope(program.Statements, nil)
+	ast.Inspect(program, l.inspect)
+
+	diagnostics := []Diagnostic{}
+	ignored := ignoredChecks(program.Comments)
+	for _, d := range l.diagnostics {
+		if !ignored[d.Line][d.Check] && !ignored[d.Line-1][d.Check] {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+
+	return diagnostics
+}
+
+// A function to return the checks ignored by the comments, by line
+func ignoredChecks(comments []*ast.Comment) map[int]map[string]bool {
+	ignored := map[int]map[string]bool{}
+	for _, comment := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Token.Literal, "//"))
+		ids, ok := strings.CutPrefix(text, "lint:ignore")
+		if !ok {
+			continue
+		}
+
+		line := comment.Token.Line
+		if ignored[line] == nil {
+			ignored[line] = map[string]bool{}
+		}
+		for _, id := range strings.FieldsFunc(ids, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			ignored[line][id] = true
+		}
+	}
+	return ignored
+}
+
+// A linter contains
+type linter struct {
+	severities  map[string]Severity
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(check string, tok token.Token, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Check:    check,
+		Severity: l.severities[check],
+		Line:     tok.Line,
+		Column:   tok.Column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// A function to turn a "line:column: message" error of the resolver into a diagnostic
+func (l *linter) resolverError(msg string) {
+	tok := token.Token{}
+	position, message, _ := strings.Cut(msg, ": ")
+	fmt.Sscanf(position, "%d:%d", &tok.Line, &tok.Column)
+	l.report("scope", tok, "%s", message)
+}
@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"testing"
+)
+
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x;", []string{}},
+		{"let x = 1;", []string{"1:5: warning: x is declared but never used (unused-variable)"}},
+		{"let _x = 1;", []string{}},
+		{"let x = 1; x = 2;", []string{"1:5: warning: x is declared but never used (unused-variable)"}},
+		{"let x = 1; x += 2;", []string{}},
+		{"let f = fn(a, b) { a; }; f;", []string{"1:15: info: parameter b is never used (unused-parameter)"}},
+		{"let x = 1; let f = fn(x) { x; }; f(x);", []string{"1:23: info: x shadows a declaration of an enclosing scope (shadow)"}},
+		{"let x = 1; while (x) { let x = 2; x; }", []string{"1:28: info: x shadows a declaration of an enclosing scope (shadow)"}},
+		{"let e = 1; try { e; } catch (e) { e; }", []string{"1:30: info: e shadows a declaration of an enclosing scope (shadow)"}},
+		{"let x = 1; let x = 2; x;", []string{}},
+		{`export let x = 1; let m = import "m"; m;`, []string{}},
+		{"export let x = 1; let f = fn(x) { x; }; f;", []string{"1:30: info: x shadows a declaration of an enclosing scope (shadow)"}},
+		{
+			"let f = fn() { return 1; 2; }; f;",
+			[]string{"1:26: warning: unreachable code after return (unreachable)"},
+		},
+		{
+			"while (true) { break; continue; }",
+			[]string{"1:23: warning: unreachable code after break (unreachable)"},
+		},
+		{"let x = 1; x == x;", []string{"1:14: warning: x is compared with itself (self-comparison)"}},
+		{"let f = fn() { 1; }; f() == f();", []string{}},
+		{"if (1 < 2) { 1; }", []string{"1:1: warning: the condition (1 < 2) is constant (constant-condition)"}},
+		{"let x = 1; if (x) { 1; }", []string{}},
+		{"y;", []string{"1:1: error: undefined variable y (scope)"}},
+	}
+
+	for _, tt := range tests {
+		diagnostics, err := Source(tt.input)
+		if err != nil {
+			t.Fatalf("Source(%q) returned an error: %s", tt.input, err)
+		}
+
+		got := []string{}
+		for _, d := range diagnostics {
+			got = append(got, d.String())
+		}
+
+		if len(got) != len(tt.expected) {
+			t.Errorf("Source(%q) does not report %d diagnostics. got = %q", tt.input, len(tt.expected), got)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("diagnostic %d of %q is not %q. got = %q", i, tt.input, tt.expected[i], got[i])
+			}
+		}
+	}
+}
+
+func TestSuppression(t *testing.T) {
+	input := `let a = 1; // lint:ignore unused-variable
+// lint:ignore shadow,unused-variable
+let b = 2;
+let c = 3; // lint:ignore shadow
+`
+
+	diagnostics, err := Source(input)
+	if err != nil {
+		t.Fatalf("Source() returned an error: %s", err)
+	}
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("Source() does not report 1 diagnostic. got = %v", diagnostics)
+	}
+
+	if diagnostics[0].Check != "unused-variable" || diagnostics[0].Line != 4 {
+		t.Errorf("the diagnostic is not unused-variable on line 4. got = %s", diagnostics[0])
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	diagnostics, err := Source("len;", "len")
+	if err != nil {
+		t.Fatalf("Source() returned an error: %s", err)
+	}
+
+	if len(diagnostics) != 0 {
+		t.Errorf("Source() reports diagnostics for a builtin. got = %v", diagnostics)
+	}
+}
@@ -0,0 +1,445 @@
+package lsp
+
+// A language server for Monkey
+//
+// The server speaks the Language Server Protocol over a pair of streams, e.g. stdin and stdout
+// It keeps every open document parsed and resolved, and publishes
+// the parse errors, or the lint diagnostics of a document that parses, whenever it changes
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/lint"
+	"Chapter_2/parser"
+	"Chapter_2/resolver"
+	"Chapter_2/token"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// A document contains
+type document struct {
+	uri     string
+	program *ast.Program // resolved, and partial if the text does not parse
+	lines   []string     // the lines of the text, to convert the columns of the lexer into LSP positions
+}
+
+// A Server contains
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	builtins  []string
+	documents map[string]*document // the open documents, by URI
+	shutdown  bool                 // true after a shutdown request
+	writeErr  error                // the error of publishing diagnostics, which stops the server
+}
+
+// Create a server that reads from in and writes to out
+// The builtins are the names the documents may use without declaring them
+func NewServer(in io.Reader, out io.Writer, builtins ...string) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		builtins:  builtins,
+		documents: map[string]*document{},
+	}
+}
+
+// A function to serve requests until the client sends exit or closes the input
+func (s *Server) Run() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: parseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, respErr := s.handle(req)
+		if s.writeErr != nil {
+			return s.writeErr
+		}
+
+		// Notifications get no response
+		if req.ID == nil {
+			continue
+		}
+		if err := s.reply(req.ID, result, respErr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) reply(id json.RawMessage, result any, respErr *responseError) error {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	if respErr != nil {
+		return writeMessage(s.out, errorResponse{JSONRPC: "2.0", ID: id, Error: *respErr})
+	}
+	return writeMessage(s.out, response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) notify(method string, params any) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// A function to decode the parameters of a request
+func decode(params json.RawMessage, v any) *responseError {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: invalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// A function to handle a request or a notification and return its result
+func (s *Server) handle(req request) (any, *responseError) {
+	// After a shutdown only exit is expected
+	if s.shutdown {
+		return nil, &responseError{Code: invalidRequest, Message: "the server is shut down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":       1, // full document sync
+				"hoverProvider":          true,
+				"definitionProvider":     true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]any{},
+			},
+			"serverInfo": map[string]any{"name": "monkey"},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		s.open(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) > 0 {
+			s.open(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+		return nil, nil
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		// Clear the diagnostics of the closed document
+		s.publish(params.TextDocument.URI, []Diagnostic{})
+		return nil, nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params), nil
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params), nil
+
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return []DocumentSymbol{}, nil
+		}
+		return doc.symbols(doc.program), nil
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params), nil
+	}
+
+	if req.ID == nil {
+		// Unknown notifications, e.g. "initialized" or "$/cancelRequest", are ignored
+		return nil, nil
+	}
+	return nil, &responseError{Code: methodNotFound, Message: fmt.Sprintf("method %s not found", req.Method)}
+}
+
+// A function to parse, resolve and lint a document and publish its diagnostics
+func (s *Server) open(uri string, text string) {
+	p := parser.NewParser(lexer.NewLexer(text))
+	program := p.ParseProgram()
+	doc := &document{uri: uri, program: program, lines: strings.Split(text, "\n")}
+	s.documents[uri] = doc
+
+	diagnostics := []Diagnostic{}
+	if len(p.Errors()) > 0 {
+		for _, msg := range p.Errors() {
+			diagnostics = append(diagnostics, doc.parseErrorDiagnostic(msg))
+		}
+		// Resolve what did parse, for hover and definition
+		resolver.New(s.builtins...).Resolve(program)
+	} else {
+		for _, d := range lint.Program(program, s.builtins...) {
+			diagnostics = append(diagnostics, doc.lintDiagnostic(d))
+		}
+	}
+
+	s.publish(uri, diagnostics)
+}
+
+// A function to publish the diagnostics of a document
+// A failed write is kept for Run to return, as the client can no longer be reached
+func (s *Server) publish(uri string, diagnostics []Diagnostic) {
+	if err := s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics}); err != nil && s.writeErr == nil {
+		s.writeErr = err
+	}
+}
+
+// A function to turn a "line:column: message" parse error into a diagnostic
+func (doc *document) parseErrorDiagnostic(msg string) Diagnostic {
+	var line, column int
+	position, message, _ := strings.Cut(msg, ": ")
+	fmt.Sscanf(position, "%d:%d", &line, &column)
+
+	start := doc.position(line, column)
+	return Diagnostic{
+		Range:    Range{Start: start, End: start},
+		Severity: SeverityError,
+		Source:   "monkey",
+		Message:  message,
+	}
+}
+
+func (doc *document) lintDiagnostic(d lint.Diagnostic) Diagnostic {
+	severity := SeverityInformation
+	switch d.Severity {
+	case lint.Error:
+		severity = SeverityError
+	case lint.Warning:
+		severity = SeverityWarning
+	}
+
+	start := doc.position(d.Line, d.Column)
+	return Diagnostic{
+		Range:    Range{Start: start, End: start},
+		Severity: severity,
+		Code:     d.Check,
+		Source:   "monkey lint",
+		Message:  d.Message,
+	}
+}
+
+// A function to return the range of a token
+func (doc *document) tokenRange(tok token.Token) Range {
+	return Range{Start: doc.position(tok.Line, tok.Column), End: doc.position(tok.Line, tok.Column+len(tok.Literal))}
+}
+
+// A function to turn a line and a column of the lexer into an LSP position
+// The lexer counts both from 1, and the columns in bytes
+// LSP counts both from 0, and the characters in UTF-16 code units
+func (doc *document) position(line int, column int) Position {
+	line, column = max(line-1, 0), max(column-1, 0)
+	if line >= len(doc.lines) {
+		return Position{Line: line, Character: column}
+	}
+
+	text := doc.lines[line]
+	// A column past the end of the line, e.g. at the end of the input, counts the bytes after it as units
+	past := max(column-len(text), 0)
+	return Position{Line: line, Character: len(utf16.Encode([]rune(text[:column-past]))) + past}
+}
+
+// A function to return the innermost node whose token is at a position, nil if there is none
+func (s *Server) nodeAt(uri string, position Position) ast.Node {
+	doc, ok := s.documents[uri]
+	if !ok {
+		return nil
+	}
+
+	var found ast.Node
+	ast.Inspect(doc.program, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		tok := ast.TokenOf(node)
+		r := doc.tokenRange(tok)
+		if tok.Line > 0 && r.Start.Line == position.Line &&
+			r.Start.Character <= position.Character && position.Character < r.End.Character {
+			// The children are visited after their parent, so the innermost node wins
+			found = node
+		}
+		return true
+	})
+
+	return found
+}
+
+// A function to describe the node at a position: its kind, and its binding for a variable
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	node := s.nodeAt(params.TextDocument.URI, params.Position)
+	if node == nil {
+		return nil
+	}
+	doc := s.documents[params.TextDocument.URI]
+
+	tok := ast.TokenOf(node)
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+	value := fmt.Sprintf("`%s`: %s", tok.Literal, kind)
+
+	if variable, ok := node.(*ast.Variable); ok {
+		value += "\n\n" + describeBinding(variable.Binding)
+	}
+
+	r := doc.tokenRange(tok)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: &r}
+}
+
+func describeBinding(binding *ast.Binding) string {
+	switch {
+	case binding == nil:
+		return "unresolved"
+	case binding.Scope == ast.BuiltinScope:
+		return "builtin"
+	case binding.Declaration == nil:
+		return fmt.Sprintf("%s binding %d", strings.ToLower(string(binding.Scope)), binding.Index)
+	}
+	return fmt.Sprintf("%s binding %d, declared at %s",
+		strings.ToLower(string(binding.Scope)), binding.Index, binding.Declaration.Token.Position())
+}
+
+// A function to return the declaration of the variable at a position, nil if there is none
+func (s *Server) definition(params TextDocumentPositionParams) *Location {
+	variable, ok := s.nodeAt(params.TextDocument.URI, params.Position).(*ast.Variable)
+	if !ok || variable.Binding == nil || variable.Binding.Declaration == nil {
+		return nil
+	}
+
+	doc := s.documents[params.TextDocument.URI]
+	return &Location{URI: params.TextDocument.URI, Range: doc.tokenRange(variable.Binding.Declaration.Token)}
+}
+
+// A function to return the let bindings of a node as symbols
+// A binding to a function literal is a function whose children are its parameters and lets
+func (doc *document) symbols(node ast.Node) []DocumentSymbol {
+	result := []DocumentSymbol{}
+	ast.Inspect(node, func(node ast.Node) bool {
+		let, ok := node.(*ast.LetStatement)
+		if !ok || let.Variable == nil {
+			return node != nil
+		}
+
+		symbol := DocumentSymbol{
+			Name:           let.Variable.Literal,
+			Kind:           SymbolKindVariable,
+			Range:          doc.tokenRange(let.Variable.Token),
+			SelectionRange: doc.tokenRange(let.Variable.Token),
+		}
+
+		if function, ok := let.Expression.(*ast.FunctionLiteral); ok {
+			symbol.Kind = SymbolKindFunction
+			for _, parameter := range function.Parameters {
+				symbol.Children = append(symbol.Children, DocumentSymbol{
+					Name:           parameter.Literal,
+					Kind:           SymbolKindVariable,
+					Range:          doc.tokenRange(parameter.Token),
+					SelectionRange: doc.tokenRange(parameter.Token),
+				})
+			}
+			if function.Body != nil {
+				symbol.Children = append(symbol.Children, doc.symbols(function.Body)...)
+			}
+		} else if let.Expression != nil {
+			symbol.Children = doc.symbols(let.Expression)
+		}
+
+		result = append(result, symbol)
+		return false
+	})
+	return result
+}
+
+// A function to return the keywords, the builtins and the names declared in a document
+func (s *Server) completion(params TextDocumentPositionParams) []CompletionItem {
+	items := []CompletionItem{}
+
+	keywords := []string{}
+	for keyword := range token.Keywords {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		items = append(items, CompletionItem{Label: keyword, Kind: CompletionKindKeyword})
+	}
+
+	for _, builtin := range s.builtins {
+		items = append(items, CompletionItem{Label: builtin, Kind: CompletionKindFunction})
+	}
+
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return items
+	}
+
+	// The names declared in the document, in source order, each once
+	functions := map[*ast.Variable]bool{}
+	seen := map[string]bool{}
+	ast.Inspect(doc.program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			if _, ok := node.Expression.(*ast.FunctionLiteral); ok {
+				functions[node.Variable] = true
+			}
+		case *ast.Variable:
+			if node.Binding == nil || node.Binding.Declaration != node || seen[node.Literal] {
+				return true
+			}
+			seen[node.Literal] = true
+			kind := CompletionKindVariable
+			if functions[node] {
+				kind = CompletionKindFunction
+			}
+			items = append(items, CompletionItem{Label: node.Literal, Kind: kind})
+		}
+		return true
+	})
+
+	return items
+}
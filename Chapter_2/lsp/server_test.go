@@ -0,0 +1,295 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// A client talks to a server running in the same process
+type client struct {
+	t      *testing.T
+	in     *io.PipeWriter // the input of the server
+	out    *bufio.Reader  // the output of the server
+	nextID int
+	done   chan error
+}
+
+// A message from the server: a response or a notification
+type serverMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, in: clientOut, out: bufio.NewReader(clientIn), done: make(chan error, 1)}
+	go func() {
+		err := NewServer(serverIn, serverOut, "len").Run()
+		serverOut.Close()
+		c.done <- err
+	}()
+
+	c.request("initialize", map[string]any{}, nil)
+	c.notify("initialized", map[string]any{})
+
+	return c
+}
+
+func (c *client) read() serverMessage {
+	content, err := readMessage(c.out)
+	if err != nil {
+		c.t.Fatalf("reading a message failed: %s", err)
+	}
+
+	var message serverMessage
+	if err := json.Unmarshal(content, &message); err != nil {
+		c.t.Fatalf("the message %s is invalid: %s", content, err)
+	}
+	return message
+}
+
+func (c *client) notify(method string, params any) {
+	if err := writeMessage(c.in, map[string]any{"jsonrpc": "2.0", "method": method, "params": params}); err != nil {
+		c.t.Fatalf("writing %s failed: %s", method, err)
+	}
+}
+
+// A function to send a request and decode the result of its response into result
+func (c *client) request(method string, params any, result any) {
+	c.nextID += 1
+	id := c.nextID
+	if err := writeMessage(c.in, map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		c.t.Fatalf("writing %s failed: %s", method, err)
+	}
+
+	message := c.read()
+	if message.ID == nil || *message.ID != id {
+		c.t.Fatalf("the response to %s has not the id %d. got = %+v", method, id, message)
+	}
+	if message.Error != nil {
+		c.t.Fatalf("%s failed: %s", method, message.Error.Message)
+	}
+	if result != nil {
+		if err := json.Unmarshal(message.Result, result); err != nil {
+			c.t.Fatalf("the result of %s is invalid: %s", method, err)
+		}
+	}
+}
+
+// A function to open a document and return the diagnostics the server publishes for it
+func (c *client) open(uri string, text string) []Diagnostic {
+	c.notify("textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "languageId": "monkey", "version": 1, "text": text}})
+
+	message := c.read()
+	if message.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("the server did not publish diagnostics. got = %+v", message)
+	}
+
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(message.Params, &params); err != nil {
+		c.t.Fatalf("the diagnostics are invalid: %s", err)
+	}
+	if params.URI != uri {
+		c.t.Fatalf("the diagnostics are not for %s. got = %s", uri, params.URI)
+	}
+	return params.Diagnostics
+}
+
+func (c *client) close() {
+	c.request("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		c.t.Errorf("the server failed: %s", err)
+	}
+}
+
+func position(uri string, line, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	}
+}
+
+const source = `let add = fn(a, b) {
+	let sum = a + b;
+	sum;
+};
+add(1, len);
+`
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+
+	diagnostics := c.open("file:///bad.mk", "let x = 1;\nlet = 5;")
+	if len(diagnostics) == 0 {
+		t.Fatalf("the server did not publish the parse errors")
+	}
+	if diagnostics[0].Severity != SeverityError || diagnostics[0].Range.Start.Line != 1 {
+		t.Errorf("the first diagnostic is not an error on line 1. got = %+v", diagnostics[0])
+	}
+
+	diagnostics = c.open("file:///lint.mk", "let x = 1;\ny;")
+	if len(diagnostics) != 2 {
+		t.Fatalf("the server did not publish 2 diagnostics. got = %+v", diagnostics)
+	}
+	if diagnostics[0].Code != "unused-variable" || diagnostics[1].Code != "scope" {
+		t.Errorf("the diagnostics are not unused-variable and scope. got = %+v", diagnostics)
+	}
+
+	if diagnostics := c.open("file:///good.mk", source); len(diagnostics) != 0 {
+		t.Errorf("the server published diagnostics for valid code. got = %+v", diagnostics)
+	}
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+	c.open("file:///a.mk", source)
+
+	tests := []struct {
+		line      int
+		character int
+		expected  string
+	}{
+		{2, 1, "`sum`: Variable\n\nlocal binding 2, declared at 2:6"},
+		{1, 15, "`b`: Variable\n\nlocal binding 1, declared at 1:17"},
+		{4, 0, "`add`: Variable\n\nglobal binding 0, declared at 1:5"},
+		{4, 8, "`len`: Variable\n\nbuiltin"},
+		{4, 4, "`1`: IntegerLiteral"},
+	}
+
+	for _, tt := range tests {
+		var hover Hover
+		c.request("textDocument/hover", position("file:///a.mk", tt.line, tt.character), &hover)
+
+		if hover.Contents.Value != tt.expected {
+			t.Errorf("the hover at %d:%d is not %q. got = %q", tt.line, tt.character, tt.expected, hover.Contents.Value)
+		}
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+	c.open("file:///a.mk", source)
+
+	var location Location
+	c.request("textDocument/definition", position("file:///a.mk", 1, 15), &location)
+	expected := Range{Start: Position{Line: 0, Character: 16}, End: Position{Line: 0, Character: 17}}
+	if location.URI != "file:///a.mk" || location.Range != expected {
+		t.Errorf("the definition of b is not %+v. got = %+v", expected, location)
+	}
+
+	var none *Location
+	c.request("textDocument/definition", position("file:///a.mk", 4, 8), &none)
+	if none != nil {
+		t.Errorf("a builtin has a definition. got = %+v", none)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+	c.open("file:///a.mk", source)
+
+	var symbols []DocumentSymbol
+	c.request("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": "file:///a.mk"}}, &symbols)
+
+	if len(symbols) != 1 || symbols[0].Name != "add" || symbols[0].Kind != SymbolKindFunction {
+		t.Fatalf("the symbols are not the function add. got = %+v", symbols)
+	}
+
+	children := []string{}
+	for _, child := range symbols[0].Children {
+		children = append(children, child.Name)
+	}
+	if strings.Join(children, ",") != "a,b,sum" {
+		t.Errorf("the children of add are not a, b and sum. got = %v", children)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+	c.open("file:///a.mk", source)
+
+	var items []CompletionItem
+	c.request("textDocument/completion", position("file:///a.mk", 4, 0), &items)
+
+	kinds := map[string]int{}
+	for _, item := range items {
+		kinds[item.Label] = item.Kind
+	}
+
+	expected := map[string]int{
+		"let":    CompletionKindKeyword,
+		"return": CompletionKindKeyword,
+		"len":    CompletionKindFunction,
+		"add":    CompletionKindFunction,
+		"sum":    CompletionKindVariable,
+		"a":      CompletionKindVariable,
+	}
+	for label, kind := range expected {
+		if kinds[label] != kind {
+			t.Errorf("the completion %s is not of kind %d. got = %d", label, kind, kinds[label])
+		}
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+
+	c.nextID += 1
+	writeMessage(c.in, map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": "textDocument/unknown"})
+	message := c.read()
+	if message.Error == nil || message.Error.Code != methodNotFound {
+		t.Errorf("the response is not a method-not-found error. got = %+v", message)
+	}
+}
+
+func TestUTF16Positions(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+
+	// "é" is 2 bytes and 1 UTF-16 unit, "😀" is 4 bytes and 2 UTF-16 units
+	diagnostics := c.open("file:///utf16.mk", "let s = \"é😀\"; y; s;")
+	if len(diagnostics) != 1 || diagnostics[0].Range.Start.Character != 15 {
+		t.Fatalf("the diagnostic for y does not start at character 15. got = %+v", diagnostics)
+	}
+
+	var hover Hover
+	c.request("textDocument/hover", position("file:///utf16.mk", 0, 15), &hover)
+	if hover.Range == nil || hover.Range.Start.Character != 15 || hover.Range.End.Character != 16 {
+		t.Errorf("the hover of y does not range over characters 15 to 16. got = %+v", hover.Range)
+	}
+}
+
+// A writer that always fails, like a client that went away
+type brokenWriter struct{}
+
+func (brokenWriter) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestPublishError(t *testing.T) {
+	for _, method := range []string{"textDocument/didOpen", "textDocument/didClose"} {
+		var in bytes.Buffer
+		params := map[string]any{"textDocument": map[string]any{"uri": "file:///a.mk", "text": "let x = 1;"}}
+		writeMessage(&in, map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+
+		if err := NewServer(&in, brokenWriter{}).Run(); err != io.ErrClosedPipe {
+			t.Errorf("the server did not fail to publish the diagnostics of %s. got = %v", method, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/parser"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A function to run "monkey ast [--json|--dot|--sexp] [--trace] file.mk"
+// It prints the parsed program of the file and returns the exit code
+func runAst(args []string, out io.Writer, errOut io.Writer) int {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	asJSON := flags.Bool("json", false, "print the AST as JSON")
+	asDOT := flags.Bool("dot", false, "print the AST as a Graphviz DOT graph")
+	asSExpression := flags.Bool("sexp", false, "print the AST as an S-expression")
+	trace := flags.Bool("trace", false, "trace the parser to stderr")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(errOut, "usage: monkey ast [--json|--dot|--sexp] [--trace] file.mk")
+		return 2
+	}
+
+	var traceOut io.Writer
+	if *trace {
+		traceOut = errOut
+	}
+	program, ok := parseFile(flags.Arg(0), errOut, traceOut)
+	if !ok {
+		return 1
+	}
+
+	switch {
+	case *asDOT:
+		fmt.Fprint(out, ast.DOT(program))
+		return 0
+	case *asSExpression:
+		fmt.Fprint(out, ast.SExpression(program))
+		return 0
+	case !*asJSON:
+		fmt.Fprintln(out, program.String())
+		return 0
+	}
+
+	data, err := ast.MarshalJSON(program)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+	var indented bytes.Buffer
+	json.Indent(&indented, data, "", "  ")
+	fmt.Fprintln(out, indented.String())
+
+	return 0
+}
+
+// A function to read and parse a file
+// Parse errors are printed to errOut, and the parser is traced to traceOut if it is not nil
+func parseFile(path string, errOut io.Writer, traceOut io.Writer) (*ast.Program, bool) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return nil, false
+	}
+
+	p := parser.NewParser(lexer.NewLexer(string(source)))
+	if traceOut != nil {
+		p.SetTrace(traceOut)
+	}
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(errOut, "%s:%s\n", path, msg)
+		}
+		return nil, false
+	}
+
+	return program, true
+}
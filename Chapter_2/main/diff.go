@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The number of unchanged lines shown around each change
+const diffContext = 3
+
+// A function to return a unified diff between two texts, "" if they are equal
+func unifiedDiff(path string, before string, after string) string {
+	if before == after {
+		return ""
+	}
+
+	a := splitLines(before)
+	b := splitLines(after)
+	edits := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s (formatted)\n", path, path)
+
+	// Group the edits into hunks of changes with their context
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].kind == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend the hunk while the changes are close together
+		first := max(start-diffContext, 0)
+		end := start
+		for end < len(edits) {
+			if edits[end].kind != ' ' {
+				end++
+				continue
+			}
+			// Count the unchanged lines up to the next change
+			next := end
+			for next < len(edits) && edits[next].kind == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		last := min(end+diffContext, len(edits))
+
+		hunk := edits[first:last]
+		aStart, bStart := hunk[0].aLine, hunk[0].bLine
+		aCount, bCount := 0, 0
+		for _, e := range hunk {
+			if e.kind != '+' {
+				aCount++
+			}
+			if e.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, e := range hunk {
+			fmt.Fprintf(&out, "%c%s\n", e.kind, e.text)
+		}
+
+		start = last
+	}
+
+	return out.String()
+}
+
+// An edit is one line of a diff
+type edit struct {
+	kind  byte   // ' ' for an unchanged line, '-' for a removed line, '+' for an added line
+	text  string // the line without its newline
+	aLine int    // the line number in the first text where the edit applies, counting from 1
+	bLine int    // the line number in the second text where the edit applies, counting from 1
+}
+
+// A function to compute the edits from a to b with a longest common subsequence
+func diffLines(a []string, b []string) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := []edit{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{kind: ' ', text: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{kind: '-', text: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+		default:
+			edits = append(edits, edit{kind: '+', text: b[j], aLine: i + 1, bLine: j + 1})
+			j++
+		}
+	}
+
+	return edits
+}
+
+// A function to split a text into lines without their newlines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
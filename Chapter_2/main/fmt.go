@@ -0,0 +1,88 @@
+package main
+
+import (
+	"Chapter_2/format"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A function to run "monkey fmt [-w] [-d] [file.mk ...]"
+// Without files it formats stdin to stdout
+// It returns the exit code
+func runFmt(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	write := flags.Bool("w", false, "write the result to the file instead of stdout")
+	showDiff := flags.Bool("d", false, "print a diff instead of the formatted source")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(errOut, "fmt: cannot use -w with standard input")
+			return 2
+		}
+		source, err := io.ReadAll(in)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+		return formatSource("<stdin>", string(source), *showDiff, out, errOut)
+	}
+
+	exitCode := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			exitCode = 1
+			continue
+		}
+
+		if !*write {
+			if code := formatSource(path, string(source), *showDiff, out, errOut); code != 0 {
+				exitCode = code
+			}
+			continue
+		}
+
+		formatted, err := format.Source(string(source))
+		if err != nil {
+			fmt.Fprintf(errOut, "%s:%s\n", path, err)
+			exitCode = 1
+			continue
+		}
+		if formatted == string(source) {
+			continue
+		}
+		if *showDiff {
+			fmt.Fprint(out, unifiedDiff(path, string(source), formatted))
+		}
+		if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+			fmt.Fprintln(errOut, err)
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+// A function to print the formatted source, or its diff with the original
+func formatSource(path string, source string, showDiff bool, out io.Writer, errOut io.Writer) int {
+	formatted, err := format.Source(source)
+	if err != nil {
+		fmt.Fprintf(errOut, "%s:%s\n", path, err)
+		return 1
+	}
+
+	if showDiff {
+		fmt.Fprint(out, unifiedDiff(path, source, formatted))
+	} else {
+		fmt.Fprint(out, formatted)
+	}
+
+	return 0
+}
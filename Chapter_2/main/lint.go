@@ -0,0 +1,72 @@
+package main
+
+import (
+	"Chapter_2/evaluator"
+	"Chapter_2/lint"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A function to run "monkey lint [-checks] [file.mk ...]"
+// Without files it lints stdin
+// It returns 1 if an error or a warning is reported, the exit code otherwise
+func runLint(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	listChecks := flags.Bool("checks", false, "list the checks and their severities")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *listChecks {
+		for _, check := range lint.Checks {
+			fmt.Fprintf(out, "%-20s %-8s %s\n", check.ID, check.Severity, check.Summary)
+		}
+		return 0
+	}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(in)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+		return lintSource("<stdin>", string(source), out, errOut)
+	}
+
+	exitCode := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			exitCode = 1
+			continue
+		}
+		if code := lintSource(path, string(source), out, errOut); code != 0 {
+			exitCode = code
+		}
+	}
+
+	return exitCode
+}
+
+// A function to print the diagnostics of a source file
+func lintSource(path string, source string, out io.Writer, errOut io.Writer) int {
+	diagnostics, err := lint.Source(source, append(evaluator.BuiltinNames(), argsName)...)
+	if err != nil {
+		fmt.Fprintf(errOut, "%s:%s\n", path, err)
+		return 1
+	}
+
+	exitCode := 0
+	for _, d := range diagnostics {
+		fmt.Fprintf(out, "%s:%s\n", path, d)
+		if d.Severity != lint.Info {
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
@@ -1,19 +1,154 @@
 package main
 
 import (
+	"Chapter_2/evaluator"
+	"Chapter_2/lsp"
 	"Chapter_2/repl"
+	_ "Chapter_2/stdlib/math"
+	_ "Chapter_2/stdlib/strings"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/user"
 )
 
+const usage = `usage:
+  monkey [-O] [--color=auto|always|never]   start the REPL, or run stdin if it is not a terminal
+  monkey [-O] -e <src>                      evaluate src and print its value
+  monkey [-O] file.mk [args]                run a script
+  monkey run [-O] file.mk [args]            run a script, "-" for stdin
+  monkey eval [-O] <src>                    evaluate src and print its value
+  monkey repl [-O] [--color=...]            start the REPL
+  monkey check [file.mk ...]                only parse, and report the errors
+  monkey ast|fmt|lint|lsp ...               see "monkey <command> -h"
+
+A script gets the arguments after its file as "args", an array of strings.
+
+Imported modules are looked for next to the importing file,
+then in the directories of $MONKEYPATH, separated by ":"
+The libraries "strings" and "math" are imported by name, e.g. import "math"
+`
+
 func main() {
-	user, err := user.Current()
+	os.Exit(run(os.Args[1:]))
+}
+
+// A function to run the command line and return the exit code
+func run(args []string) int {
+	// Subcommands
+	if len(args) > 0 {
+		switch args[0] {
+		case "run":
+			return runRun(args[1:], os.Stdin, os.Stdout, os.Stderr)
+		case "eval":
+			return runEval(args[1:], os.Stdout, os.Stderr)
+		case "check":
+			return runCheck(args[1:], os.Stdin, os.Stdout, os.Stderr)
+		case "repl":
+			return runRepl(args[1:], os.Stdin, os.Stdout, os.Stderr)
+		case "ast":
+			return runAst(args[1:], os.Stdout, os.Stderr)
+		case "fmt":
+			return runFmt(args[1:], os.Stdin, os.Stdout, os.Stderr)
+		case "lint":
+			return runLint(args[1:], os.Stdin, os.Stdout, os.Stderr)
+		case "lsp":
+			// The language server talks to the editor over stdin and stdout
+			if err := lsp.NewServer(os.Stdin, os.Stdout, append(evaluator.BuiltinNames(), argsName)...).Run(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			return 0
+		}
+	}
+
+	flags := flag.NewFlagSet("monkey", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	optimize := flags.Bool("O", false, "optimize before evaluating")
+	source := flags.String("e", "", "evaluate a source and print its value")
+	color := flags.String("color", "auto", "color the REPL: auto, always or never")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	switch {
+	case isFlagSet(flags, "e"):
+		value, code := runSource("", *source, nil, *optimize, os.Stderr)
+		printValue(value, os.Stdout)
+		return code
+	case flags.NArg() > 0:
+		return runFile(flags.Arg(0), flags.Args()[1:], os.Stdin, *optimize, os.Stderr)
+	case !repl.IsTerminal(os.Stdin):
+		// A script piped in, e.g. "echo 'puts(1)' | monkey"
+		return runFile("-", nil, os.Stdin, *optimize, os.Stderr)
+	}
+
+	colorMode, err := repl.ParseColorMode(*color)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return startRepl(colorMode, *optimize, os.Stdin, os.Stdout)
+}
+
+// A function to run "monkey repl [-O] [--color=auto|always|never]"
+// The REPL runs even if stdin is not a terminal
+func runRepl(args []string, in *os.File, out *os.File, errOut io.Writer) int {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	optimize := flags.Bool("O", false, "optimize the input before evaluating it")
+	color := flags.String("color", "auto", "color the REPL: auto, always or never")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	colorMode, err := repl.ParseColorMode(*color)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(errOut, err)
+		return 2
+	}
+	return startRepl(colorMode, *optimize, in, out)
+}
+
+// A function to greet the user on a terminal and run the REPL
+func startRepl(colorMode repl.ColorMode, optimize bool, in *os.File, out *os.File) int {
+	if repl.IsTerminal(in) {
+		fmt.Fprintf(out, "Hello%s, Welcome to Monekey Programming Langauge!\n", userName())
+		fmt.Fprintf(out, "Feel free to type in commands, :help lists the commands\n")
 	}
 
-	fmt.Printf("Hello %s, Welcome to Monekey Programming Langauge!\n", user.Name)
-	fmt.Printf("Feel free to type in commands\n")
-	repl.Start(os.Stdin, os.Stdout)
+	r := repl.New(out)
+	r.Color = repl.UseColor(colorMode, out)
+	r.Optimize = optimize
+	r.ModulePath = modulePath()
+	r.Run(in)
+	return 0
+}
+
+// A function to return the name of the user with a leading space, "" if it is unknown
+// user.Current fails in some minimal containers, so $USER is the fallback
+func userName() string {
+	if current, err := user.Current(); err == nil {
+		if current.Name != "" {
+			return " " + current.Name
+		}
+		return " " + current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return " " + name
+	}
+	return ""
+}
+
+// A function to report whether a flag was given on the command line, even empty
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
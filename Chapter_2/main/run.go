@@ -0,0 +1,170 @@
+package main
+
+import (
+	"Chapter_2/evaluator"
+	"Chapter_2/lexer"
+	"Chapter_2/object"
+	"Chapter_2/parser"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// A function to run "monkey run [-O] file.mk [args]"
+// The file "-" is stdin
+// It returns 1 if the script does not parse or raises an uncaught error
+func runRun(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	optimize := flags.Bool("O", false, "optimize the script before running it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(errOut, "usage: monkey run [-O] file.mk [args]")
+		return 2
+	}
+
+	return runFile(flags.Arg(0), flags.Args()[1:], in, *optimize, errOut)
+}
+
+// A function to run a script file, or stdin for "-", with the arguments after it on the command line
+func runFile(path string, args []string, in io.Reader, optimize bool, errOut io.Writer) int {
+	var source []byte
+	var err error
+	if path == "-" {
+		path = "<stdin>"
+		source, err = io.ReadAll(in)
+	} else {
+		source, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	_, code := runSource(path, string(source), args, optimize, errOut)
+	return code
+}
+
+// A function to run "monkey eval [-O] <src>", also "monkey -e <src>"
+// It prints the value of the source, unless it has none
+func runEval(args []string, out io.Writer, errOut io.Writer) int {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	optimize := flags.Bool("O", false, "optimize the source before evaluating it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(errOut, "usage: monkey eval [-O] <src>")
+		return 2
+	}
+
+	value, code := runSource("", flags.Arg(0), nil, *optimize, errOut)
+	printValue(value, out)
+	return code
+}
+
+// A function to print a value, unless it has none
+func printValue(value object.Object, out io.Writer) {
+	if value != nil && value != evaluator.NULL {
+		fmt.Fprintln(out, value.Inspect())
+	}
+}
+
+// The variable that holds the arguments of a script, an array of strings
+const argsName = "args"
+
+// A function to parse and evaluate a source in a new environment, with args bound to argsName
+// Errors are printed to errOut, uncaught ones with their stack trace pointing into the file
+// It returns the value of the source and the exit code
+func runSource(path string, source string, args []string, optimize bool, errOut io.Writer) (object.Object, int) {
+	p := parser.NewParser(lexer.NewLexer(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		printParserErrors(path, p.Errors(), errOut)
+		return nil, 1
+	}
+
+	env := object.NewEnvironment()
+	env.SetFile(path)
+	elements := []object.Object{}
+	for _, arg := range args {
+		elements = append(elements, &object.String{Value: arg})
+	}
+	env.Set(argsName, &object.Array{Elements: elements})
+	loader := evaluator.NewLoader(modulePath()...)
+	loader.Optimize = optimize
+	env.SetImporter(loader)
+	value, err := evaluator.EvalProgram(program, env, optimize)
+	if err != nil {
+		fmt.Fprint(errOut, err.StackTrace())
+		return nil, 1
+	}
+	return value, 0
+}
+
+// A function to return the directories searched for imported modules, from $MONKEYPATH
+// e.g. MONKEYPATH=~/monkey/lib:/usr/share/monkey, like $PATH
+func modulePath() []string {
+	return filepath.SplitList(os.Getenv("MONKEYPATH"))
+}
+
+// A function to run "monkey check [file.mk ...]"
+// It only parses the files, or stdin without files, and prints their errors
+// It returns 1 if a file does not parse
+func runCheck(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	check := func(path string, source string) int {
+		p := parser.NewParser(lexer.NewLexer(source))
+		p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			printParserErrors(path, p.Errors(), errOut)
+			return 1
+		}
+		return 0
+	}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(in)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+		return check("<stdin>", string(source))
+	}
+
+	exitCode := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			exitCode = 1
+			continue
+		}
+		if code := check(path, string(source)); code != 0 {
+			exitCode = code
+		}
+	}
+
+	return exitCode
+}
+
+// A function to print parse errors prefixed with their file, e.g. "rules.mk:3:5: ..."
+func printParserErrors(path string, errors []string, errOut io.Writer) {
+	for _, msg := range errors {
+		if path == "" {
+			fmt.Fprintln(errOut, msg)
+		} else {
+			fmt.Fprintf(errOut, "%s:%s\n", path, msg)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSourceArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "[]"},
+		{[]string{"a", "b c"}, "[a, b c]"},
+	}
+
+	for _, tt := range tests {
+		var errOut bytes.Buffer
+		value, code := runSource("", "args", tt.args, false, &errOut)
+		if code != 0 {
+			t.Fatalf("runSource() failed with %d: %s", code, errOut.String())
+		}
+		if value.Inspect() != tt.expected {
+			t.Errorf("args is not %s. got = %s", tt.expected, value.Inspect())
+		}
+	}
+}
+
+func TestRunScriptArgs(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "args.mk")
+	source := `if (len(args) != 2) { throw "len"; }
+if (args[0] != "-v") { throw "flag"; }
+if (args[1] + "!" != "x!") { throw "string"; }`
+	if err := os.WriteFile(script, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flags after the file are the script's, not run's
+	var errOut bytes.Buffer
+	if code := runRun([]string{"-O", script, "-v", "x"}, strings.NewReader(""), &errOut, &errOut); code != 0 {
+		t.Errorf("the script failed with %d: %s", code, errOut.String())
+	}
+}
@@ -0,0 +1,110 @@
+package object
+
+import "sort"
+
+// An Environment binds names to values
+// The environment of a block or a call is enclosed by the one it was created in
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+	file  string // the file the code comes from, "" to use the one of the outer environment
+	frame *Frame // the call the environment belongs to, nil to use the one of the outer environment
+
+	importer Importer // the importer of the modules, nil to use the one of the outer environment
+}
+
+// Create an empty top-level environment
+func NewEnvironment() *Environment {
+	return &Environment{store: map[string]Object{}}
+}
+
+// Create an empty environment enclosed by outer
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// A function to look up a name in the environment and then in the enclosing ones
+func (e *Environment) Get(name string) (Object, bool) {
+	for env := e; env != nil; env = env.outer {
+		if value, ok := env.store[name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// A function to bind a name in this environment, e.g. for a let
+func (e *Environment) Set(name string, value Object) Object {
+	e.store[name] = value
+	return value
+}
+
+// A function to change the value of a name where it is bound
+// It returns false if the name is not bound
+func (e *Environment) Assign(name string, value Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = value
+			return true
+		}
+	}
+	return false
+}
+
+// A function to return the names bound in this environment, sorted
+func (e *Environment) Names() []string {
+	names := []string{}
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A function to set the file the code evaluated in the environment comes from
+func (e *Environment) SetFile(file string) {
+	e.file = file
+}
+
+// A function to return the file the code evaluated in the environment comes from
+// It is "" for code that does not come from a file, e.g. typed in the REPL
+func (e *Environment) File() string {
+	for env := e; env != nil; env = env.outer {
+		if env.file != "" {
+			return env.file
+		}
+	}
+	return ""
+}
+
+// A function to set the call the environment belongs to
+func (e *Environment) SetFrame(frame *Frame) {
+	e.frame = frame
+}
+
+// A function to return the innermost call being evaluated, nil at the top level
+func (e *Environment) Frame() *Frame {
+	for env := e; env != nil; env = env.outer {
+		if env.frame != nil {
+			return env.frame
+		}
+	}
+	return nil
+}
+
+// A function to set the importer of the modules imported in the environment
+func (e *Environment) SetImporter(importer Importer) {
+	e.importer = importer
+}
+
+// A function to return the importer of the modules, nil if there is none
+func (e *Environment) Importer() Importer {
+	for env := e; env != nil; env = env.outer {
+		if env.importer != nil {
+			return env.importer
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// The name of the frame of the top level of a module while it is imported
+const ModuleFrame = "<module>"
+
+// A Frame is a function call, or an import of a module, being evaluated
+type Frame struct {
+	Function string // the name of the called function, ModuleFrame for an import
+	File     string // the file of the call, "" if it does not come from a file
+	Line     int    // the position of the call
+	Column   int
+	Caller   *Frame // the call the call was made from, nil for a call from the top level
+	Depth    int    // the number of calls being evaluated, 1 for a call from the top level
+}
+
+// A TraceEntry is a line of a stack trace: a function and the position it is at
+type TraceEntry struct {
+	Function string
+	File     string
+	Line     int
+	Column   int
+}
+
+func (te TraceEntry) String() string {
+	file := te.File
+	if file == "" {
+		file = "<input>"
+	}
+	return fmt.Sprintf("%s:%d:%d", file, te.Line, te.Column)
+}
+
+// An Error is a type of Object, made by a failure or a throw statement
+// It is raised inside an Exception
+type Error struct {
+	Message string
+	Value   Object // the value of a throw statement, nil for a failure
+	File    string // the position the error was raised at
+	Line    int
+	Column  int
+	Frame   *Frame // the innermost call when the error was raised, nil at the top level
+}
+
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+
+// A function to return the stack trace of the error, innermost call first
+// The last entry is the top level of the program, called "main"
+func (e *Error) Trace() []TraceEntry {
+	name := func(frame *Frame) string {
+		if frame == nil {
+			return "main"
+		}
+		return frame.Function
+	}
+
+	trace := []TraceEntry{{Function: name(e.Frame), File: e.File, Line: e.Line, Column: e.Column}}
+	for frame := e.Frame; frame != nil; frame = frame.Caller {
+		trace = append(trace, TraceEntry{Function: name(frame.Caller), File: frame.File, Line: frame.Line, Column: frame.Column})
+	}
+	return trace
+}
+
+// A function to print the error and its stack trace like a Go panic:
+//
+//	ERROR: division by zero
+//
+//	divide(...)
+//		rules.mk:2:11
+//	main()
+//		rules.mk:5:1
+//
+// The top level of an imported module is printed as <module>, without arguments
+func (e *Error) StackTrace() string {
+	var out bytes.Buffer
+
+	out.WriteString(e.Inspect() + "\n")
+	for _, entry := range e.Trace() {
+		if entry.Function == "main" {
+			out.WriteString("\nmain()\n")
+		} else if entry.Function == ModuleFrame {
+			out.WriteString("\n" + ModuleFrame + "\n")
+		} else {
+			out.WriteString("\n" + entry.Function + "(...)\n")
+		}
+		out.WriteString("\t" + entry.String())
+	}
+	out.WriteString("\n")
+
+	return out.String()
+}
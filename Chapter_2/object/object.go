@@ -0,0 +1,243 @@
+package object
+
+// The values of Monkey programs
+
+import (
+	"Chapter_2/ast"
+	"bytes"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Alias for string
+type ObjectType string
+
+const (
+	INTEGER_OBJ  = "INTEGER"
+	FLOAT_OBJ    = "FLOAT"
+	BOOLEAN_OBJ  = "BOOLEAN"
+	STRING_OBJ   = "STRING"
+	NULL_OBJ     = "NULL"
+	ARRAY_OBJ    = "ARRAY"
+	FUNCTION_OBJ = "FUNCTION"
+	BUILTIN_OBJ  = "BUILTIN"
+	ERROR_OBJ    = "ERROR"
+	MODULE_OBJ   = "MODULE"
+
+	// Signals that unwind the evaluation, never seen by a program
+	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
+	EXCEPTION_OBJ    = "EXCEPTION"
+)
+
+// An Object is a value
+type Object interface {
+	Type() ObjectType // An object must be able to return its type
+	Inspect() string  // An object must have a string representation
+}
+
+// An Integer is a type of Object
+// Its value is in Value when it fits in an int64, and in Big otherwise,
+// so two equal integers always have the same representation
+type Integer struct {
+	Value int64
+	Big   *big.Int // nil when the value fits in an int64
+}
+
+func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (i *Integer) Inspect() string {
+	if i.Big != nil {
+		return i.Big.String()
+	}
+	return strconv.FormatInt(i.Value, 10)
+}
+
+// A function to return the value of an integer as a big.Int
+func (i *Integer) BigValue() *big.Int {
+	if i.Big != nil {
+		return i.Big
+	}
+	return big.NewInt(i.Value)
+}
+
+// Create an integer from a big.Int, stored as an int64 if it fits
+func NewBigInteger(value *big.Int) *Integer {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+	return &Integer{Big: value}
+}
+
+// A Float is a type of Object
+// Its value is always finite, the operations that would make an infinity or a NaN fail instead
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string  { return ast.FormatFloat(f.Value) }
+
+// A function to return the integer as the nearest float64
+// It returns false if the integer is too big for a float64
+func (i *Integer) Float64() (float64, bool) {
+	if i.Big == nil {
+		return float64(i.Value), true
+	}
+	value, _ := new(big.Float).SetInt(i.Big).Float64()
+	return value, !math.IsInf(value, 0)
+}
+
+// A function to compare two numbers, integers or floats, exactly
+// It returns -1 if a < b, 0 if a == b and +1 if a > b, and panics if one is not a number
+func CompareNumbers(a, b Object) int {
+	return exactValue(a).Cmp(exactValue(b))
+}
+
+// A function to return a number as a big.Float with all its digits
+func exactValue(number Object) *big.Float {
+	switch number := number.(type) {
+	case *Integer:
+		return new(big.Float).SetInt(number.BigValue())
+	case *Float:
+		return new(big.Float).SetFloat64(number.Value)
+	}
+	panic("object: " + string(number.Type()) + " is not a number")
+}
+
+// A Boolean is a type of Object
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+func (b *Boolean) Inspect() string  { return strconv.FormatBool(b.Value) }
+
+// A String is a type of Object
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Inspect() string  { return s.Value }
+
+// A Null is a type of Object, the value of an expression without a value
+type Null struct{}
+
+func (n *Null) Type() ObjectType { return NULL_OBJ }
+func (n *Null) Inspect() string  { return "null" }
+
+// An Array is a type of Object
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string {
+	elements := []string{}
+	for _, element := range a.Elements {
+		elements = append(elements, element.Inspect())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// A Function is a type of Object, a function literal with the environment it closes over
+type Function struct {
+	Name       string // the name of the let the function was bound to first, "" if none
+	Parameters []*ast.Variable
+	Body       *ast.BlockStatement
+	Env        *Environment
+	File       string // the file the function was defined in, "" if none
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+
+	parameters := []string{}
+	for _, parameter := range f.Parameters {
+		parameters = append(parameters, parameter.String())
+	}
+
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(parameters, ", "))
+	out.WriteString(") ")
+	out.WriteString(f.Body.String())
+
+	return out.String()
+}
+
+// A function type for the Go functions behind builtins
+// A builtin reports a failure by returning an *Error
+type BuiltinFunction func(args ...Object) Object
+
+// A Builtin is a type of Object
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin function " + b.Name }
+
+// A Module is a type of Object, the value of an import
+// Only its exported top-level bindings can be read, e.g. util["f"]
+type Module struct {
+	Name    string       // the file of the module, as it was found
+	Env     *Environment // the environment its top level was evaluated in
+	Exports []string     // the names of its exported bindings, in source order
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "module " + m.Name }
+
+// A function to return the value of an exported binding
+func (m *Module) Export(name string) (Object, bool) {
+	for _, export := range m.Exports {
+		if export == name {
+			return m.Env.Get(name)
+		}
+	}
+	return nil, false
+}
+
+// An Importer loads the modules a program imports
+// It returns the module, or the signal of the error that stopped it from loading
+type Importer interface {
+	Import(node *ast.ImportExpression, env *Environment) Object
+}
+
+// A ReturnValue wraps the value of a return statement while it unwinds to the function
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
+
+// A Break unwinds to the loop it breaks out of
+type Break struct {
+	Label string // "" for the innermost loop
+}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break " + b.Label }
+
+// A Continue unwinds to the loop it continues
+type Continue struct {
+	Label string // "" for the innermost loop
+}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue " + c.Label }
+
+// An Exception unwinds with an error to the try statement that catches it
+// The caught error is an ordinary value again
+type Exception struct {
+	Error *Error
+}
+
+func (e *Exception) Type() ObjectType { return EXCEPTION_OBJ }
+func (e *Exception) Inspect() string  { return e.Error.Inspect() }
@@ -0,0 +1,303 @@
+package optimizer
+
+// Constant folding and dead-branch elimination
+//
+// The optimizer rewrites an AST in place, from the leaves up:
+// operations on literals become literals, e.g. "60 * 60 * 24" becomes "86400",
+// and the branches of an if that can never run are removed
+// Operations that would fail at run time, e.g. "1 / 0", are left alone
+// so they still fail at run time
+//
+// Only literals are folded: "-(-x)" stays as it is, because x may not be a number,
+// and then negating it must fail at run time
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/token"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// A function to optimize a node and its children
+// It returns the optimized node, which may be a new node, e.g. a literal for an operation
+func Optimize(node ast.Node) ast.Node {
+	return ast.Modify(node, optimize)
+}
+
+// The modifier of Optimize, called on every node after its children
+func optimize(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		return foldPrefix(node)
+
+	case *ast.InfixExpression:
+		return foldInfix(node)
+
+	case *ast.IfExpression:
+		return pruneIf(node)
+
+	case *ast.BlockStatement:
+		node.Statements = removePrunedIfs(node.Statements)
+
+	case *ast.Program:
+		node.Statements = removePrunedIfs(node.Statements)
+	}
+
+	return node
+}
+
+func foldPrefix(expression *ast.PrefixExpression) ast.Expression {
+	switch right := expression.Right.(type) {
+	case *ast.Boolean:
+		if expression.Operator == "!" {
+			return newBoolean(expression.Token, !right.Value)
+		}
+
+	case *ast.IntegerLiteral:
+		if expression.Operator == "-" {
+			return newInteger(expression.Token, new(big.Int).Neg(integerValue(right)))
+		}
+
+	case *ast.FloatLiteral:
+		if expression.Operator == "-" {
+			return newFloat(expression.Token, -right.Value)
+		}
+	}
+
+	return expression
+}
+
+func foldInfix(expression *ast.InfixExpression) ast.Expression {
+	// An operation with a float is done on floats, whatever the type of the other number
+	_, leftFloat := expression.LeftValue.(*ast.FloatLiteral)
+	_, rightFloat := expression.RightValue.(*ast.FloatLiteral)
+	if leftFloat || rightFloat {
+		if folded := foldFloats(expression); folded != nil {
+			return folded
+		}
+		return expression
+	}
+
+	switch left := expression.LeftValue.(type) {
+	case *ast.IntegerLiteral:
+		if right, ok := expression.RightValue.(*ast.IntegerLiteral); ok {
+			if folded := foldIntegers(expression, integerValue(left), integerValue(right)); folded != nil {
+				return folded
+			}
+		}
+
+	case *ast.StringLiteral:
+		if right, ok := expression.RightValue.(*ast.StringLiteral); ok {
+			switch expression.Operator {
+			case "+":
+				return newString(expression.Token, left, right)
+			case "==":
+				return newBoolean(expression.Token, left.Value == right.Value)
+			case "!=":
+				return newBoolean(expression.Token, left.Value != right.Value)
+			}
+		}
+
+	case *ast.Boolean:
+		if right, ok := expression.RightValue.(*ast.Boolean); ok {
+			switch expression.Operator {
+			case "==":
+				return newBoolean(expression.Token, left.Value == right.Value)
+			case "!=":
+				return newBoolean(expression.Token, left.Value != right.Value)
+			}
+		}
+	}
+
+	return expression
+}
+
+// A function to fold an operation on two integers
+// It returns nil if the operation is unknown or fails at run time
+func foldIntegers(expression *ast.InfixExpression, left, right *big.Int) ast.Expression {
+	tok := expression.Token
+
+	switch expression.Operator {
+	case "+":
+		return newInteger(tok, new(big.Int).Add(left, right))
+	case "-":
+		return newInteger(tok, new(big.Int).Sub(left, right))
+	case "*":
+		return newInteger(tok, new(big.Int).Mul(left, right))
+	case "/":
+		// Division by zero stays a runtime error
+		if right.Sign() == 0 {
+			return nil
+		}
+		// Quo truncates towards zero, like Go's integer division
+		return newInteger(tok, new(big.Int).Quo(left, right))
+	case "<":
+		return newBoolean(tok, left.Cmp(right) < 0)
+	case ">":
+		return newBoolean(tok, left.Cmp(right) > 0)
+	case "==":
+		return newBoolean(tok, left.Cmp(right) == 0)
+	case "!=":
+		return newBoolean(tok, left.Cmp(right) != 0)
+	}
+
+	return nil
+}
+
+// A function to fold an operation on two numbers, one of them a float
+// The numbers are compared exactly, and converted to float64 for the arithmetic, like the evaluator does
+// It returns nil if an operand is not a number, or the operation is unknown or fails at run time
+func foldFloats(expression *ast.InfixExpression) ast.Expression {
+	left, ok := numberValue(expression.LeftValue)
+	if !ok {
+		return nil
+	}
+	right, ok := numberValue(expression.RightValue)
+	if !ok {
+		return nil
+	}
+	tok := expression.Token
+
+	switch expression.Operator {
+	case "<":
+		return newBoolean(tok, left.Cmp(right) < 0)
+	case ">":
+		return newBoolean(tok, left.Cmp(right) > 0)
+	case "==":
+		return newBoolean(tok, left.Cmp(right) == 0)
+	case "!=":
+		return newBoolean(tok, left.Cmp(right) != 0)
+	}
+
+	// An integer too big for a float64 stays a runtime error
+	a, _ := left.Float64()
+	b, _ := right.Float64()
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return nil
+	}
+
+	var result float64
+	switch expression.Operator {
+	case "+":
+		result = a + b
+	case "-":
+		result = a - b
+	case "*":
+		result = a * b
+	case "/":
+		// Division by zero stays a runtime error
+		if b == 0 {
+			return nil
+		}
+		result = a / b
+	default:
+		return nil
+	}
+
+	// So does an overflow
+	if math.IsInf(result, 0) {
+		return nil
+	}
+	return newFloat(tok, result)
+}
+
+// A function to remove the branch of an if that is never taken
+// The taken branch of a constant condition is always the consequence:
+// "if (false) { a } else { b }" becomes "if (true) { b }",
+// and "if (false) { a }" becomes "if (false) {}"
+// The branches are blocks with their own scope, so they are not spliced into the enclosing code
+func pruneIf(expression *ast.IfExpression) ast.Expression {
+	condition, ok := expression.Condition.(*ast.Boolean)
+	if !ok {
+		return expression
+	}
+
+	switch {
+	case condition.Value:
+		expression.Alternative = nil
+	case expression.Alternative != nil:
+		expression.Condition = newBoolean(condition.Token, true)
+		expression.Consequence = expression.Alternative
+		expression.Alternative = nil
+	default:
+		expression.Consequence = &ast.BlockStatement{Token: expression.Consequence.Token, Statements: []ast.Statement{}, End: expression.Consequence.End}
+	}
+
+	return expression
+}
+
+// A function to remove the if statements that never run any code
+// The last statement is kept, because its value is the value of the block or the program:
+// "if (false) {}" gives null like the if it replaces
+func removePrunedIfs(statements []ast.Statement) []ast.Statement {
+	kept := []ast.Statement{}
+	for i, s := range statements {
+		if statement, ok := s.(*ast.ExpressionStatement); ok && i < len(statements)-1 {
+			if expression, ok := statement.Expression.(*ast.IfExpression); ok && isPruned(expression) {
+				continue
+			}
+		}
+		kept = append(kept, s)
+	}
+	return kept
+}
+
+// A function to report whether an if never runs any code
+func isPruned(expression *ast.IfExpression) bool {
+	condition, ok := expression.Condition.(*ast.Boolean)
+	return ok && !condition.Value && expression.Alternative == nil
+}
+
+// A function to return the value of an integer literal as a big.Int
+func integerValue(literal *ast.IntegerLiteral) *big.Int {
+	if literal.Big != nil {
+		return literal.Big
+	}
+	return big.NewInt(literal.Value)
+}
+
+// A function to create an integer literal at the position of tok
+func newInteger(tok token.Token, value *big.Int) *ast.IntegerLiteral {
+	literal := &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: value.String(), Line: tok.Line, Column: tok.Column}}
+	if value.IsInt64() {
+		literal.Value = value.Int64()
+	} else {
+		literal.Big = value
+	}
+	return literal
+}
+
+// A function to return the value of an integer or float literal, with all its digits
+func numberValue(expression ast.Expression) (*big.Float, bool) {
+	switch literal := expression.(type) {
+	case *ast.IntegerLiteral:
+		return new(big.Float).SetInt(integerValue(literal)), true
+	case *ast.FloatLiteral:
+		return new(big.Float).SetFloat64(literal.Value), true
+	}
+	return nil, false
+}
+
+// A function to create a float literal at the position of tok
+func newFloat(tok token.Token, value float64) *ast.FloatLiteral {
+	return &ast.FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: ast.FormatFloat(value), Line: tok.Line, Column: tok.Column}, Value: value}
+}
+
+// A function to create the string literal of two strings joined, at the position of tok
+// The raw texts are joined too, so the string keeps its escapes
+func newString(tok token.Token, left, right *ast.StringLiteral) *ast.StringLiteral {
+	raw := strings.TrimSuffix(strings.TrimPrefix(left.String(), `"`), `"`) +
+		strings.TrimSuffix(strings.TrimPrefix(right.String(), `"`), `"`)
+	return &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: raw, Line: tok.Line, Column: tok.Column}, Value: left.Value + right.Value}
+}
+
+// A function to create a boolean literal at the position of tok
+func newBoolean(tok token.Token, value bool) *ast.Boolean {
+	tokenType := token.TokenType(token.FALSE)
+	if value {
+		tokenType = token.TRUE
+	}
+	return &ast.Boolean{Token: token.Token{Type: tokenType, Literal: strconv.FormatBool(value), Line: tok.Line, Column: tok.Column}, Value: value}
+}
@@ -0,0 +1,84 @@
+package optimizer
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/parser"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"60 * 60 * 24;", "86400"},
+		{"1 + 2 * 3 - 4 / 2;", "5"},
+		{"7 / -2;", "-3"},
+		{"-(-x);", "(-(-x))"},
+		{"-(-(x / 0 * 2.5));", "(-(-((x / 0) * 2.5)))"},
+		{"-(-(1 / 0 * 2.5));", "(-(-((1 / 0) * 2.5)))"},
+		{`-(-"a");`, `(-(-"a"))`},
+		{"- -5;", "5"},
+		{"!true;", "false"},
+		{"!!false;", "false"},
+		{"1 < 2 == true;", "true"},
+		{"3 > 4 != false;", "false"},
+		{"x * (2 + 3);", "(x * 5)"},
+		{`"a\n" + "b" + "c";`, `"a\nbc"`},
+		{`"a" == "a";`, "true"},
+		{`"a" != "a";`, "false"},
+		{"9223372036854775807 + 1;", "9223372036854775808"},
+		{"1.5 * 2;", "3.0"},
+		{"0.1 + 0.2;", "0.30000000000000004"},
+		{"-2.5 + 1;", "-1.5"},
+		{"1 == 1.0;", "true"},
+		{"9007199254740993 == 9007199254740992.0;", "false"},
+		{"2 * 3.5 > 6;", "true"},
+		// Runtime errors stay runtime errors
+		{"1 / 0;", "(1 / 0)"},
+		{"(2 + 3) / (1 - 1);", "(5 / 0)"},
+		{"1 + true;", "(1 + true)"},
+		{`"a" - "b";`, `("a" - "b")`},
+		{"1.5 / 0;", "(1.5 / 0)"},
+		{"1e308 * 10;", "(1e308 * 10)"},
+		{"1.5 + true;", "(1.5 + true)"},
+		// Dead branches
+		{"if (1 < 2) { a; } else { b; }", "if (true) { a }"},
+		{"if (!true) { a; } else { b; }", "if (true) { b }"},
+		{"if (false) { a; }", "if (false) {  }"},
+		{"if (false) { a; } b;", "b"},
+		{"let x = if (false) { a; };", "let x = if (false) {  };"},
+		{"if (x) { 2 * 3; } else { b; }", "if (x) { 6 } else { b }"},
+		{"while (x) { if (false) { a; } b; }", "while (x) { b }"},
+	}
+
+	for _, tt := range tests {
+		p := parser.NewParser(lexer.NewLexer(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			t.Fatalf("parser has errors for %q: %v", tt.input, p.Errors())
+		}
+
+		optimized := Optimize(program)
+		if optimized.String() != tt.expected {
+			t.Errorf("Optimize(%q) is not %q. got = %q", tt.input, tt.expected, optimized.String())
+		}
+	}
+}
+
+func TestOptimizeBigIntegers(t *testing.T) {
+	p := parser.NewParser(lexer.NewLexer("9223372036854775808 - 1;"))
+	program := p.ParseProgram()
+
+	statement := Optimize(program).(*ast.Program).Statements[0].(*ast.ExpressionStatement)
+	literal, ok := statement.Expression.(*ast.IntegerLiteral)
+	if !ok {
+		t.Fatalf("statement.Expression is not *ast.IntegerLiteral. got = %T", statement.Expression)
+	}
+
+	// A result that fits in an int64 is stored as one
+	if literal.Big != nil || literal.Value != 9223372036854775807 {
+		t.Errorf("literal is not the int64 9223372036854775807. got = %d, %v", literal.Value, literal.Big)
+	}
+}
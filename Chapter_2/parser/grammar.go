@@ -0,0 +1,163 @@
+package parser
+
+// Operators added by embedding applications
+//
+// A Grammar lists extra prefix and infix operators, e.g. "|>", ".." or "in",
+// with their precedence, associativity and parse functions
+// NewParserWithGrammar teaches them to both the lexer and the parser
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/token"
+	"fmt"
+)
+
+// The associativity of an infix operator
+type Associativity int
+
+const (
+	LeftAssociative  Associativity = iota // "a - b - c" is "(a - b) - c"
+	RightAssociative                      // "a ** b ** c" is "a ** (b ** c)"
+)
+
+type (
+	// A prefix parse function of a Grammar
+	// It starts on the operator token and must stop on the last token of the expression
+	PrefixParseFn func(p *Parser) ast.Expression
+	// An infix parse function of a Grammar
+	// It starts on the operator token, takes the left expression,
+	// and must stop on the last token of the expression
+	InfixParseFn func(p *Parser, left ast.Expression) ast.Expression
+)
+
+// An InfixOperator contains
+type InfixOperator struct {
+	Literal       string          // the symbol or word of the operator, e.g. "|>" or "in"
+	Type          token.TokenType // the token type, by default the keyword type of Literal or Literal itself
+	Precedence    int             // the precedence, e.g. SUM or SUM + 5
+	Associativity Associativity   // LeftAssociative by default
+	Parse         InfixParseFn    // nil parses an ast.InfixExpression
+}
+
+// A PrefixOperator contains
+type PrefixOperator struct {
+	Literal string          // the symbol or word of the operator, e.g. "#"
+	Type    token.TokenType // the token type, by default the keyword type of Literal or Literal itself
+	Parse   PrefixParseFn   // nil parses an ast.PrefixExpression
+}
+
+// A Grammar contains the operators to add to the built-in ones
+type Grammar struct {
+	infix  []InfixOperator
+	prefix []PrefixOperator
+}
+
+// Create an empty grammar
+func NewGrammar() *Grammar {
+	return &Grammar{}
+}
+
+// A function to add an infix operator
+// An operator with the token type of a built-in one replaces it
+func (g *Grammar) AddInfix(operator InfixOperator) {
+	operator.Type = operatorType(operator.Literal, operator.Type)
+	g.infix = append(g.infix, operator)
+}
+
+// A function to add a prefix operator
+// An operator with the token type of a built-in one replaces it
+func (g *Grammar) AddPrefix(operator PrefixOperator) {
+	operator.Type = operatorType(operator.Literal, operator.Type)
+	g.prefix = append(g.prefix, operator)
+}
+
+// A function to return the token type of an operator
+// An unset type is the keyword type of the literal, e.g. IN for "in",
+// or the literal itself like the built-in operators, e.g. "|>"
+func operatorType(literal string, tokenType token.TokenType) token.TokenType {
+	if tokenType != "" {
+		return tokenType
+	}
+	if keyword, ok := token.Keywords[literal]; ok {
+		return keyword
+	}
+	return token.TokenType(literal)
+}
+
+// A function to register the operators of the grammar with a parser and its lexer
+func (g *Grammar) apply(p *Parser) {
+	for _, operator := range g.prefix {
+		p.lexer.RegisterOperator(operator.Literal, operator.Type)
+
+		parse := operator.Parse
+		if parse == nil {
+			p.registerPrefix(operator.Type, p.parsePrefixExpression)
+			continue
+		}
+		p.registerPrefix(operator.Type, func() ast.Expression { return parse(p) })
+	}
+
+	for _, operator := range g.infix {
+		p.lexer.RegisterOperator(operator.Literal, operator.Type)
+		p.precedences[operator.Type] = operator.Precedence
+
+		parse := operator.Parse
+		switch {
+		case parse != nil:
+			p.registerInfix(operator.Type, func(left ast.Expression) ast.Expression { return parse(p, left) })
+		case operator.Associativity == RightAssociative:
+			p.registerInfix(operator.Type, p.parseRightAssociativeInfixExpression)
+		default:
+			p.registerInfix(operator.Type, p.parseInfixExpression)
+		}
+	}
+}
+
+// A function to parse an infix expression whose operator is right-associative
+// The right side is parsed just below the operator's precedence, so it takes the next operator of the same precedence
+func (p *Parser) parseRightAssociativeInfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:     p.curToken,
+		LeftValue: left,
+		Operator:  p.curToken.Literal,
+	}
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.RightValue = p.parseExpression(precedence - 1)
+	return expression
+}
+
+// Functions for the parse functions of a Grammar
+
+// A function to return the current token
+func (p *Parser) CurToken() token.Token {
+	return p.curToken
+}
+
+// A function to return the next token
+func (p *Parser) PeekToken() token.Token {
+	return p.peekToken
+}
+
+// A function to move on to the next token
+func (p *Parser) NextToken() {
+	p.nextToken()
+}
+
+// A function to move on to the next token if it is of the expected type
+// Otherwise it adds an error and returns false
+func (p *Parser) ExpectPeek(t token.TokenType) bool {
+	return p.expectPeek(t)
+}
+
+// A function to parse an expression, starting on the current token,
+// that binds tighter than the given precedence
+func (p *Parser) ParseExpression(precedence int) ast.Expression {
+	return p.parseExpression(precedence)
+}
+
+// A function to add an error at the position of the current token
+func (p *Parser) Errorf(format string, args ...any) {
+	msg := fmt.Sprintf("%s: %s", p.curToken.Position(), fmt.Sprintf(format, args...))
+	p.errors = append(p.errors, msg)
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/token"
+	"testing"
+)
+
+func newTestGrammar() *Grammar {
+	grammar := NewGrammar()
+	// A pipe that binds looser than comparisons
+	grammar.AddInfix(InfixOperator{Literal: "|>", Precedence: ASSIGNMENT + 5})
+	// A range that binds looser than sums
+	grammar.AddInfix(InfixOperator{Literal: "..", Precedence: LESSGREATER + 5})
+	// A right-associative power that binds tighter than products
+	grammar.AddInfix(InfixOperator{Literal: "**", Precedence: PRODUCT + 5, Associativity: RightAssociative})
+	// Membership with the existing "in" keyword
+	grammar.AddInfix(InfixOperator{Literal: "in", Precedence: LESSGREATER})
+	// A prefix length operator
+	grammar.AddPrefix(PrefixOperator{Literal: "#"})
+	// "a @ b" with a custom parse function that builds an index expression
+	grammar.AddInfix(InfixOperator{
+		Literal:    "@",
+		Precedence: INDEX,
+		Parse: func(p *Parser, left ast.Expression) ast.Expression {
+			expression := &ast.IndexExpression{Token: p.CurToken(), Left: left}
+			p.NextToken()
+			expression.Index = p.ParseExpression(INDEX)
+			return expression
+		},
+	})
+	return grammar
+}
+
+func TestGrammarOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a |> b |> c", "((a |> b) |> c)"},
+		{"x == 1 |> f", "((x == 1) |> f)"},
+		{"1..n + 1", "(1 .. (n + 1))"},
+		{"a ** b ** c", "(a ** (b ** c))"},
+		{"2 * a ** 2", "(2 * (a ** 2))"},
+		{"x in xs == found", "((x in xs) == found)"},
+		{"#xs + 1", "((#xs) + 1)"},
+		{"a @ 1 + 2", "((a[1]) + 2)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParserWithGrammar(l, newTestGrammar())
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() is not %q. got = %q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestGrammarKeepsForIn(t *testing.T) {
+	l := lexer.NewLexer("for (x in a in b) { x; }")
+	p := NewParserWithGrammar(l, newTestGrammar())
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ForStatement. got = %T", program.Statements[0])
+	}
+
+	if stmt.Iterable.String() != "(a in b)" {
+		t.Errorf("stmt.Iterable is not %q. got = %q", "(a in b)", stmt.Iterable.String())
+	}
+}
+
+func TestGrammarDoesNotLeak(t *testing.T) {
+	// A parser without the grammar does not know its operators
+	l := lexer.NewLexer("a |> b")
+	p := NewParser(l)
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Errorf("parser without the grammar has no errors for %q", "a |> b")
+	}
+
+	if Precedence(token.TokenType("|>")) != LOWEST {
+		t.Errorf("the built-in precedence table was changed")
+	}
+}
+
+func TestGrammarErrorf(t *testing.T) {
+	grammar := NewGrammar()
+	grammar.AddPrefix(PrefixOperator{
+		Literal: "?",
+		Parse: func(p *Parser) ast.Expression {
+			p.Errorf("unexpected %s", p.CurToken().Literal)
+			return nil
+		},
+	})
+
+	l := lexer.NewLexer("  ?")
+	p := NewParserWithGrammar(l, grammar)
+	p.ParseProgram()
+
+	if len(p.Errors()) != 1 || p.Errors()[0] != "1:3: unexpected ?" {
+		t.Errorf("wrong errors. got = %q", p.Errors())
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"Chapter_2/lexer"
 	"Chapter_2/token"
 	"fmt"
+	"io"
+	"math/big"
 	"strconv"
 )
 
@@ -16,17 +18,21 @@ type (
 )
 
 // PRECEDENCE FOR OPERATION
+// The levels are 10 apart so that a Grammar can add levels in between, e.g. SUM + 5
 const (
-	_ int = iota
+	_ int = iota * 10
 	LOWEST
+	ASSIGNMENT  // = or +=
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
+	INDEX       // array[index]
 )
 
+// The precedences of the built-in infix operators
 var precedences = map[token.TokenType]int{
 	token.EQ:    EQUALS,
 	token.NEQ:   EQUALS,
@@ -36,6 +42,15 @@ var precedences = map[token.TokenType]int{
 	token.MINUS: SUM,
 	token.DIV:   PRODUCT,
 	token.MULT:  PRODUCT,
+
+	token.ASSIGN:      ASSIGNMENT,
+	token.PLUSASSIGN:  ASSIGNMENT,
+	token.MINUSASSIGN: ASSIGNMENT,
+	token.MULTASSIGN:  ASSIGNMENT,
+	token.DIVASSIGN:   ASSIGNMENT,
+
+	token.RLBRACKET: CALL,
+	token.SLBRACKET: INDEX,
 }
 
 type Parser struct {
@@ -46,6 +61,11 @@ type Parser struct {
 	errors        []string                          // contain all types of error when reading the program
 	prefixParseFn map[token.TokenType]prefixParseFn // contain a prefix-parser-function dictionary
 	infixParseFn  map[token.TokenType]infixParseFn  // contain a infix- parser-function dictionary
+	precedences   map[token.TokenType]int           // contain the precedences of the infix operators
+	loops         []string                          // contain the labels of the enclosing loops, "" if unlabelled
+	blockDepth    int                               // the number of enclosing blocks, 0 at the top level
+	traceOut      io.Writer                         // the writer the trace goes to, nil when tracing is off
+	traceLevel    int                               // the indentation level of the trace
 }
 
 // Debug function
@@ -58,17 +78,29 @@ func (p *Parser) PrintParser() {
 
 // Create a new parser
 func NewParser(l *lexer.Lexer) *Parser {
+	return NewParserWithGrammar(l, nil)
+}
+
+// Create a new parser that also knows the operators of a grammar
+// The operators are registered with the lexer too, so it must not have been read from yet
+func NewParserWithGrammar(l *lexer.Lexer, grammar *Grammar) *Parser {
 	p := &Parser{lexer: l, errors: []string{}}
-	// Set the current token
-	p.nextToken()
-	// Set the peek token
-	p.nextToken()
+
 	// Initialise a prefix-parse-function dictionary
 	p.prefixParseFn = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.VARIABLE, p.parseVariable)            // register a parse variable function
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)           // register a parse integer function
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)           // register a parse float function
+	p.registerPrefix(token.STRING, p.parseStringLiteral)         // register a parse string function
 	p.registerPrefix(token.EXCLAMATION, p.parsePrefixExpression) // register a parse 'not' function
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)       // register a parse 'negative' function
+	p.registerPrefix(token.RLBRACKET, p.parseGroupedExpression)  // register a parse '(...)' function
+	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)     // register a parse 'fn' function
+	p.registerPrefix(token.TRUE, p.parseBoolean)                 // register a parse 'true' function
+	p.registerPrefix(token.FALSE, p.parseBoolean)                // register a parse 'false' function
+	p.registerPrefix(token.IF, p.parseIfExpression)              // register a parse 'if' function
+	p.registerPrefix(token.SLBRACKET, p.parseArrayLiteral)       // register a parse '[...]' function
+	p.registerPrefix(token.IMPORT, p.parseImportExpression)      // register a parse 'import' function
 
 	// Initialise a prefix-parse-function dictionary
 	p.infixParseFn = make(map[token.TokenType]infixParseFn)
@@ -80,6 +112,29 @@ func NewParser(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NEQ, p.parseInfixExpression)
+	p.registerInfix(token.SLBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.RLBRACKET, p.parseCallExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUSASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUSASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MULTASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.DIVASSIGN, p.parseAssignExpression)
+
+	// Initialise the precedences with the built-in ones
+	p.precedences = make(map[token.TokenType]int)
+	for tokenType, precedence := range precedences {
+		p.precedences[tokenType] = precedence
+	}
+
+	// Add the operators of the grammar
+	if grammar != nil {
+		grammar.apply(p)
+	}
+
+	// Set the current token
+	p.nextToken()
+	// Set the peek token
+	p.nextToken()
 
 	return p
 }
@@ -98,8 +153,17 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	literal := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 
+	// The integer is too big for an int64, so keep it as a big.Int
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		bigValue, ok := new(big.Int).SetString(p.curToken.Literal, 0)
+		if ok {
+			literal.Big = bigValue
+			return literal
+		}
+	}
+
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		msg := fmt.Sprintf("%s: could not parse %q as integer", p.curToken.Position(), p.curToken.Literal)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
@@ -109,6 +173,44 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return literal
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	// A float too big for a float64 would be infinite, a float too small is rounded to zero
+	if err != nil {
+		msg := fmt.Sprintf("%s: could not parse %q as float", p.curToken.Position(), p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return &ast.FloatLiteral{Token: p.curToken, Value: value}
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	// The literal is the raw text between the quotes, so it is quoted back to decode its escapes
+	value, err := strconv.Unquote(`"` + p.curToken.Literal + `"`)
+	if err != nil {
+		msg := fmt.Sprintf("%s: invalid escape in string \"%s\"", p.curToken.Position(), p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return &ast.StringLiteral{Token: p.curToken, Value: value}
+}
+
+func (p *Parser) parseImportExpression() ast.Expression {
+	expression := &ast.ImportExpression{Token: p.curToken}
+
+	// The path must be a string literal, so modules can be found without running the program
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	path, ok := p.parseStringLiteral().(*ast.StringLiteral)
+	if !ok {
+		return nil
+	}
+	expression.Path = path
+
+	return expression
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -119,6 +221,153 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	// Skip over the "(" token
+	p.nextToken()
+	expression := p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RRBRACKET) {
+		return nil
+	}
+	return expression
+}
+
+func (p *Parser) parseBoolean() ast.Expression {
+	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
+}
+
+func (p *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{Token: p.curToken}
+
+	// The condition: (x < y)
+	if !p.expectPeek(token.RLBRACKET) {
+		return nil
+	}
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RRBRACKET) {
+		return nil
+	}
+
+	if !p.expectPeek(token.PLBRACKET) {
+		return nil
+	}
+	expression.Consequence = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.ELSE) {
+		p.nextToken()
+		if !p.expectPeek(token.PLBRACKET) {
+			return nil
+		}
+		expression.Alternative = p.parseBlockStatement()
+	}
+
+	return expression
+}
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	literal := &ast.FunctionLiteral{Token: p.curToken}
+
+	// The parameters: (x, y)
+	if !p.expectPeek(token.RLBRACKET) {
+		return nil
+	}
+	literal.Parameters = p.parseFunctionParameters()
+	if literal.Parameters == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.PLBRACKET) {
+		return nil
+	}
+
+	// A break or continue in the body cannot refer to a loop outside the function
+	loops := p.loops
+	p.loops = nil
+	literal.Body = p.parseBlockStatement()
+	p.loops = loops
+
+	return literal
+}
+
+// A function to parse the parameters of a function literal
+// The current token must be "(" and is left on ")"
+// It returns nil if the parameters are malformed
+func (p *Parser) parseFunctionParameters() []*ast.Variable {
+	parameters := []*ast.Variable{}
+
+	// No parameters
+	if p.peekTokenIs(token.RRBRACKET) {
+		p.nextToken()
+		return parameters
+	}
+
+	if !p.expectPeek(token.VARIABLE) {
+		return nil
+	}
+	parameters = append(parameters, &ast.Variable{Token: p.curToken, Literal: p.curToken.Literal})
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.VARIABLE) {
+			return nil
+		}
+		parameters = append(parameters, &ast.Variable{Token: p.curToken, Literal: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.RRBRACKET) {
+		return nil
+	}
+
+	return parameters
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	expression := &ast.CallExpression{Token: p.curToken, Function: function}
+	expression.Arguments = p.parseExpressionList(token.RRBRACKET)
+	if expression.Arguments == nil {
+		return nil
+	}
+	return expression
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.SRBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
+	array.End = p.curToken
+	return array
+}
+
+// A function to parse a comma-separated list of expressions, e.g. the arguments of a call
+// The current token must be the opening bracket and is left on the closing one, end
+// It returns nil if the list is malformed
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	// An empty list
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
 		Token:     p.curToken,
@@ -131,15 +380,60 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{Token: p.curToken, Left: left}
+	// Skip over the "[" token
+	p.nextToken()
+	expression.Index = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.SRBRACKET) {
+		return nil
+	}
+	return expression
+}
+
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   left,
+		Operator: p.curToken.Literal,
+	}
+	p.nextToken()
+	// Assignment is right-associative: "a = b = c" is "a = (b = c)"
+	// so the right side is parsed one level below ASSIGNMENT
+	expression.Value = p.parseExpression(ASSIGNMENT - 1)
+
+	// Only variables and index expressions can be assigned to
+	switch left.(type) {
+	case *ast.Variable, *ast.IndexExpression:
+		return expression
+	case nil:
+		// The left side already failed to parse
+		return nil
+	default:
+		msg := fmt.Sprintf("%s: cannot assign to %s", expression.Token.Position(), left.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
+// A function to return the precedence of a built-in infix operator token
+// It returns LOWEST if the token is not a built-in infix operator
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
+}
+
 func (p *Parser) peekPrecedence() int {
-	if p, ok := precedences[p.peekToken.Type]; ok {
+	if p, ok := p.precedences[p.peekToken.Type]; ok {
 		return p
 	}
 	return LOWEST
 }
 
 func (p *Parser) curPrecedence() int {
-	if p, ok := precedences[p.curToken.Type]; ok {
+	if p, ok := p.precedences[p.curToken.Type]; ok {
 		return p
 	}
 	return LOWEST
@@ -168,6 +462,11 @@ func (p *Parser) ParseProgram() *ast.Program {
 		p.nextToken()
 	}
 
+	// Keep the comments the lexer skipped
+	for _, comment := range p.lexer.Comments() {
+		program.Comments = append(program.Comments, &ast.Comment{Token: comment})
+	}
+
 	return program
 }
 
@@ -176,14 +475,38 @@ func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
 		return p.parseLetStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.TRY:
+		return p.parseTryStatement()
+	case token.WHILE:
+		return p.parseWhileStatement(nil)
+	case token.FOR:
+		return p.parseForStatement(nil)
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
+	case token.VARIABLE:
+		// A variable followed by ":" labels a loop
+		if p.peekTokenIs(token.COLON) {
+			return p.parseLabelledStatement()
+		}
+		return p.parseExpressionStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
 }
 
-func (p *Parser) parseLetStatement() *ast.LetStatement {
+func (p *Parser) parseLetStatement() ast.Statement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseLetStatement"))
+	}
+
 	// Set the current token of the parser on the current statement
 	stmt := &ast.LetStatement{Token: p.curToken}
 
@@ -200,29 +523,365 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
-	// TODO: We're skipping the expressions until we encounter a semicolon
-	for !p.curTokenIs(token.SEMICOLON) {
+	// Skip over the ASSIGN token
+	p.nextToken()
+
+	stmt.Expression = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
+func (p *Parser) parseExportStatement() ast.Statement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseExportStatement"))
+	}
+
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	// Only the top-level bindings of a module can be exported
+	if p.blockDepth > 0 {
+		msg := fmt.Sprintf("%s: export is only allowed at the top level", stmt.Token.Position())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	if !p.expectPeek(token.LET) {
+		return nil
+	}
+	let, ok := p.parseLetStatement().(*ast.LetStatement)
+	if !ok {
+		return nil
+	}
+	stmt.Statement = let
+
+	return stmt
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseReturnStatement"))
+	}
+
 	stmt := &ast.ReturnStatement{Token: p.curToken}
+
+	// A return without a value
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+		return stmt
+	}
+
 	// Skip over the Return token
 	p.nextToken()
 
-	// TODO: We're skipping the expressions until we
-	// encounter a semicolon
-	for !p.curTokenIs(token.SEMICOLON) {
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseThrowStatement"))
+	}
+
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+	// Skip over the Throw token
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseTryStatement() ast.Statement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseTryStatement"))
+	}
+
+	stmt := &ast.TryStatement{Token: p.curToken}
+
+	// The guarded block
+	if !p.expectPeek(token.PLBRACKET) {
+		return nil
+	}
+	stmt.Block = p.parseBlockStatement()
+
+	// The optional catch clause: catch (e) { ... }
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+		if !p.expectPeek(token.RLBRACKET) {
+			return nil
+		}
+		if !p.expectPeek(token.VARIABLE) {
+			return nil
+		}
+		stmt.CatchParam = &ast.Variable{Token: p.curToken, Literal: p.curToken.Literal}
+		if !p.expectPeek(token.RRBRACKET) {
+			return nil
+		}
+		if !p.expectPeek(token.PLBRACKET) {
+			return nil
+		}
+		stmt.Catch = p.parseBlockStatement()
+	}
+
+	// The optional finally clause: finally { ... }
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+		if !p.expectPeek(token.PLBRACKET) {
+			return nil
+		}
+		stmt.Finally = p.parseBlockStatement()
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		msg := fmt.Sprintf("%s: try must be followed by catch or finally", stmt.Token.Position())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	// An optional ";" may follow the block
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseLabelledStatement() ast.Statement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseLabelledStatement"))
+	}
+
+	label := &ast.Variable{Token: p.curToken, Literal: p.curToken.Literal}
+	// Skip over the ":" token
+	p.nextToken()
+
+	for _, enclosing := range p.loops {
+		if enclosing == label.Literal {
+			msg := fmt.Sprintf("%s: label %s is already used by an enclosing loop", label.Token.Position(), label.Literal)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+	}
+
+	// Only loops can be labelled
+	switch p.peekToken.Type {
+	case token.WHILE:
+		p.nextToken()
+		return p.parseWhileStatement(label)
+	case token.FOR:
+		p.nextToken()
+		return p.parseForStatement(label)
+	default:
+		msg := fmt.Sprintf("%s: Expect the label %s to be followed by a loop, got %s instead", p.peekToken.Position(), label.Literal, p.peekToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
+func (p *Parser) parseWhileStatement(label *ast.Variable) ast.Statement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseWhileStatement"))
+	}
+
+	stmt := &ast.WhileStatement{Token: p.curToken, Label: label}
+
+	// The condition: (cond)
+	if !p.expectPeek(token.RLBRACKET) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RRBRACKET) {
+		return nil
+	}
+
+	if !p.expectPeek(token.PLBRACKET) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody(label)
+
+	// An optional ";" may follow the block
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement(label *ast.Variable) ast.Statement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseForStatement"))
+	}
+
+	stmt := &ast.ForStatement{Token: p.curToken, Label: label}
+
+	// The header: (x in iterable)
+	if !p.expectPeek(token.RLBRACKET) {
+		return nil
+	}
+	if !p.expectPeek(token.VARIABLE) {
+		return nil
+	}
+	stmt.Variable = &ast.Variable{Token: p.curToken, Literal: p.curToken.Literal}
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RRBRACKET) {
+		return nil
+	}
+
+	if !p.expectPeek(token.PLBRACKET) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody(label)
+
+	// An optional ";" may follow the block
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
+// A function to parse the body of a loop
+// so that break and continue inside it know which loops enclose them
+func (p *Parser) parseLoopBody(label *ast.Variable) *ast.BlockStatement {
+	name := ""
+	if label != nil {
+		name = label.Literal
+	}
+
+	p.loops = append(p.loops, name)
+	body := p.parseBlockStatement()
+	p.loops = p.loops[:len(p.loops)-1]
+
+	return body
+}
+
+func (p *Parser) parseBreakStatement() ast.Statement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseBreakStatement"))
+	}
+
+	stmt := &ast.BreakStatement{Token: p.curToken}
+	stmt.Label = p.parseLoopControlLabel()
+
+	if !p.checkLoopControl(stmt.Token, stmt.Label) {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() ast.Statement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseContinueStatement"))
+	}
+
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+	stmt.Label = p.parseLoopControlLabel()
+
+	if !p.checkLoopControl(stmt.Token, stmt.Label) {
+		return nil
+	}
+
+	return stmt
+}
+
+// A function to read the optional label and semicolon after break or continue
+func (p *Parser) parseLoopControlLabel() *ast.Variable {
+	var label *ast.Variable
+
+	if p.peekTokenIs(token.VARIABLE) {
+		p.nextToken()
+		label = &ast.Variable{Token: p.curToken, Literal: p.curToken.Literal}
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return label
+}
+
+// A function to check that break or continue is inside a loop
+// and that its label, if any, belongs to an enclosing loop
+func (p *Parser) checkLoopControl(tok token.Token, label *ast.Variable) bool {
+	if len(p.loops) == 0 {
+		msg := fmt.Sprintf("%s: %s outside of a loop", tok.Position(), tok.Literal)
+		p.errors = append(p.errors, msg)
+		return false
+	}
+
+	if label == nil {
+		return true
+	}
+
+	for _, enclosing := range p.loops {
+		if enclosing == label.Literal {
+			return true
+		}
+	}
+
+	msg := fmt.Sprintf("%s: %s to undefined label %s", label.Token.Position(), tok.Literal, label.Literal)
+	p.errors = append(p.errors, msg)
+	return false
+}
+
+// A function to parse the statements between "{" and "}"
+// The current token must be "{" and is left on "}"
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseBlockStatement"))
+	}
+
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+	// Skip over the "{" token
+	p.nextToken()
+
+	p.blockDepth += 1
+	defer func() { p.blockDepth -= 1 }()
+
+	// While we haven't reached the end of the block
+	for !p.curTokenIs(token.PRBRACKET) && !p.curTokenIs(token.EOF) {
+		stmt := p.parseStatement()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	if !p.curTokenIs(token.PRBRACKET) {
+		msg := fmt.Sprintf("%s: Expect the block to be closed by %s, got %s instead", p.curToken.Position(), token.PRBRACKET, p.curToken.Type)
+		p.errors = append(p.errors, msg)
+	} else {
+		block.End = p.curToken
+	}
+
+	return block
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseExpressionStatement"))
+	}
+
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
 	stmt.Expression = p.parseExpression(LOWEST)
@@ -235,6 +894,10 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("parseExpression(" + precedenceName(precedence) + ")"))
+	}
+
 	// Get the current prefix operation
 	prefix := p.prefixParseFn[p.curToken.Type]
 
@@ -244,7 +907,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	}
 
 	// Assign the prefix
-	leftExp := prefix()
+	leftExp := p.callPrefix(prefix)
 	// While the parser hasn't reached the semicolon
 	// and
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
@@ -253,13 +916,29 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 			return leftExp
 		}
 		p.nextToken()
-		leftExp = infix(leftExp)
+		leftExp = p.callInfix(infix, leftExp)
 	}
 	return leftExp
 }
 
+// A function to call a prefix parse function, traced if tracing is on
+func (p *Parser) callPrefix(prefix prefixParseFn) ast.Expression {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("prefix " + parseFnName(prefix)))
+	}
+	return prefix()
+}
+
+// A function to call an infix parse function, traced if tracing is on
+func (p *Parser) callInfix(infix infixParseFn, left ast.Expression) ast.Expression {
+	if p.traceOut != nil {
+		defer p.untrace(p.trace("infix " + parseFnName(infix) + "(" + precedenceName(p.curPrecedence()) + ")"))
+	}
+	return infix(left)
+}
+
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
+	msg := fmt.Sprintf("%s: no prefix parse function for %s found", p.curToken.Position(), t)
 	p.errors = append(p.errors, msg)
 }
 
@@ -289,6 +968,6 @@ func (p *Parser) Errors() []string {
 }
 
 func (p *Parser) peekError(expectedToken token.TokenType) {
-	msg := fmt.Sprintf("Expect the next token to be %s, got %s instead", expectedToken, p.peekToken.Type)
+	msg := fmt.Sprintf("%s: Expect the next token to be %s, got %s instead", p.peekToken.Position(), expectedToken, p.peekToken.Type)
 	p.errors = append(p.errors, msg)
 }
@@ -4,6 +4,7 @@ import (
 	"Chapter_2/ast"
 	"Chapter_2/lexer"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -48,6 +49,56 @@ let foobar = 838383;
 	}
 }
 
+func TestLetAndReturnValues(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"let x = 5;", "let x = 5;"},
+		{"let y = x + 2 * 3;", "let y = (x + (2 * 3));"},
+		{"let z = y", "let z = y;"},
+		{"return x == 1;", "return (x == 1);"},
+		{"return;", "return ;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got = %d", len(program.Statements))
+		}
+
+		if program.String() != tt.expectedProgram {
+			t.Errorf("program.String() is not %q. got = %q", tt.expectedProgram, program.String())
+		}
+	}
+}
+
+func TestProgramComments(t *testing.T) {
+	input := `// one
+let x = 5; // two
+`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got = %d", len(program.Statements))
+	}
+
+	if len(program.Comments) != 2 {
+		t.Fatalf("program.Comments does not contain 2 comments. got = %d", len(program.Comments))
+	}
+
+	if program.Comments[0].String() != "// one" || program.Comments[1].String() != "// two" {
+		t.Errorf("wrong comments. got = %q, %q", program.Comments[0], program.Comments[1])
+	}
+}
+
 func testLetStatement(t *testing.T, stmt ast.Statement, expectedVariable string) bool {
 	// If the statement token is not 'let'
 	if stmt.TokenLiteral() != "let" {
@@ -188,6 +239,197 @@ func TestIntegerLiteralExpression(t *testing.T) {
 
 }
 
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedBig bool
+		expected    string
+	}{
+		{"9223372036854775807;", false, "9223372036854775807"},
+		{"9223372036854775808;", true, "9223372036854775808"},
+		{"123456789012345678901234567890;", true, "123456789012345678901234567890"},
+		{"007;", false, "7"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not a ast.ExpressionStatement. got = %T", program.Statements[0])
+		}
+
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not a ast.IntegerLiteral. got = %T", stmt.Expression)
+		}
+
+		if (literal.Big != nil) != tt.expectedBig {
+			t.Errorf("literal.Big presence is not %t for %s", tt.expectedBig, tt.input)
+		}
+
+		if literal.String() != tt.expected {
+			t.Errorf("literal.String() is not %s. got = %s", tt.expected, literal.String())
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"0.5;", 0.5},
+		{"6.02e23;", 6.02e23},
+		{"1E-9;", 1e-9},
+		{"2e+3;", 2000},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not a ast.ExpressionStatement. got = %T", program.Statements[0])
+		}
+
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not a ast.FloatLiteral. got = %T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value is not %g. got = %g", tt.expected, literal.Value)
+		}
+
+		// A parsed float is printed as it was written
+		if literal.String() != strings.TrimSuffix(tt.input, ";") {
+			t.Errorf("literal.String() is not %s. got = %s", tt.input, literal.String())
+		}
+	}
+
+	// A float too big for a float64
+	p := NewParser(lexer.NewLexer("1e400;"))
+	p.ParseProgram()
+	if len(p.Errors()) != 1 || p.Errors()[0] != `1:1: could not parse "1e400" as float` {
+		t.Errorf("wrong errors for 1e400. got = %v", p.Errors())
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"héllo \"world\"\n";`
+
+	l := lexer.NewLexer(input)
+	parser := NewParser(l)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements has not enough statements. got = %d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not a ast.ExpressionStatement. got = %T", program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not a ast.StringLiteral. got = %T", stmt.Expression)
+	}
+
+	if literal.Value != "héllo \"world\"\n" {
+		t.Errorf("literal.Value not %q. got = %q", "héllo \"world\"\n", literal.Value)
+	}
+
+	// The string is printed back as it was written
+	if literal.String() != `"héllo \"world\"\n"` {
+		t.Errorf("literal.String() wrong. got = %s", literal.String())
+	}
+}
+
+func TestStringLiteralErrors(t *testing.T) {
+	tests := []string{
+		`"open`,
+		`"bad \q escape"`,
+	}
+
+	for _, input := range tests {
+		l := lexer.NewLexer(input)
+		p := NewParser(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("parser has no errors for %q", input)
+		}
+	}
+}
+
+func TestImportAndExport(t *testing.T) {
+	input := `let util = import "lib/util"; export let x = util["f"](1);`
+
+	l := lexer.NewLexer(input)
+	parser := NewParser(l)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got = %d", len(program.Statements))
+	}
+
+	let, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not a ast.LetStatement. got = %T", program.Statements[0])
+	}
+	importExpression, ok := let.Expression.(*ast.ImportExpression)
+	if !ok {
+		t.Fatalf("let.Expression is not a ast.ImportExpression. got = %T", let.Expression)
+	}
+	if importExpression.Path.Value != "lib/util" {
+		t.Errorf("importExpression.Path.Value not %q. got = %q", "lib/util", importExpression.Path.Value)
+	}
+
+	export, ok := program.Statements[1].(*ast.ExportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not a ast.ExportStatement. got = %T", program.Statements[1])
+	}
+	if !testLetStatement(t, export.Statement, "x") {
+		return
+	}
+
+	expected := `let util = import "lib/util";export let x = (util["f"])(1);`
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected = %q, got = %q", expected, program.String())
+	}
+}
+
+func TestImportAndExportErrors(t *testing.T) {
+	tests := []string{
+		"import lib;",
+		"export 5;",
+		"export x = 1;",
+		"fn() { export let x = 1; }",
+		"if (true) { export let x = 1; }",
+	}
+
+	for _, input := range tests {
+		l := lexer.NewLexer(input)
+		p := NewParser(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("parser has no errors for %q", input)
+		}
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTest := []struct {
 		input        string
@@ -284,6 +526,425 @@ func TestParsingInfixExpression(t *testing.T) {
 
 	}
 }
+func TestThrowStatement(t *testing.T) {
+	input := "throw 5 + 1;"
+
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got = %d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ThrowStatement. got = %T", program.Statements[0])
+	}
+
+	if stmt.Value.String() != "(5 + 1)" {
+		t.Errorf("stmt.Value is not %q. got = %q", "(5 + 1)", stmt.Value.String())
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	tests := []struct {
+		input           string
+		hasCatch        bool
+		hasFinally      bool
+		expectedProgram string
+	}{
+		{"try { throw 1; } catch (e) { e; }", true, false, "try { throw 1; } catch (e) { e }"},
+		{"try { 1; } finally { 2; }", false, true, "try { 1 } finally { 2 }"},
+		{"try { 1; } catch (err) { 2; } finally { 3; }", true, true, "try { 1 } catch (err) { 2 } finally { 3 }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got = %d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.TryStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.TryStatement. got = %T", program.Statements[0])
+		}
+
+		if (stmt.Catch != nil) != tt.hasCatch {
+			t.Errorf("stmt.Catch presence is not %t", tt.hasCatch)
+		}
+
+		if (stmt.Finally != nil) != tt.hasFinally {
+			t.Errorf("stmt.Finally presence is not %t", tt.hasFinally)
+		}
+
+		if program.String() != tt.expectedProgram {
+			t.Errorf("program.String() is not %q. got = %q", tt.expectedProgram, program.String())
+		}
+	}
+}
+
+func TestTryWithoutHandlerError(t *testing.T) {
+	l := lexer.NewLexer("try { 1; }")
+	p := NewParser(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 1 {
+		t.Fatalf("parser does not have 1 error. got = %d", len(p.Errors()))
+	}
+
+	if len(program.Statements) != 0 {
+		t.Fatalf("program.Statements is not empty. got = %d", len(program.Statements))
+	}
+}
+
+func TestLoopStatements(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"while (x < 10) { x; }", "while ((x < 10)) { x }"},
+		{"for (x in xs) { continue; }", "for (x in xs) { continue; }"},
+		{"while (x) { break; }", "while (x) { break; }"},
+		{
+			"outer: for (x in xs) { inner: while (x) { break outer; continue inner; } }",
+			"outer: for (x in xs) { inner: while (x) { break outer;continue inner; } }",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got = %d", len(program.Statements))
+		}
+
+		if program.String() != tt.expectedProgram {
+			t.Errorf("program.String() is not %q. got = %q", tt.expectedProgram, program.String())
+		}
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	l := lexer.NewLexer("outer: for (item in items) { item; }")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ForStatement. got = %T", program.Statements[0])
+	}
+
+	if stmt.Label == nil || stmt.Label.Literal != "outer" {
+		t.Errorf("stmt.Label is not %q. got = %v", "outer", stmt.Label)
+	}
+
+	if stmt.Variable.Literal != "item" {
+		t.Errorf("stmt.Variable is not %q. got = %q", "item", stmt.Variable.Literal)
+	}
+
+	if stmt.Iterable.String() != "items" {
+		t.Errorf("stmt.Iterable is not %q. got = %q", "items", stmt.Iterable.String())
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("stmt.Body does not contain 1 statement. got = %d", len(stmt.Body.Statements))
+	}
+}
+
+func TestOptionalSemicolonAfterBlockStatements(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"while (x) { x; }; x;", "while (x) { x }x"},
+		{"for (x in xs) { x; }; xs;", "for (x in xs) { x }xs"},
+		{"outer: while (x) { break outer; }; x;", "outer: while (x) { break outer; }x"},
+		{"try { 1; } catch (e) { 2; }; 3;", "try { 1 } catch (e) { 2 }3"},
+		{"try { 1; } finally { 2; }; 3;", "try { 1 } finally { 2 }3"},
+		{"let f = fn() { while (x) { x; }; }; f;", "let f = fn() { while (x) { x } };f"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 2 {
+			t.Fatalf("program.Statements of %q does not contain 2 statements. got = %d", tt.input, len(program.Statements))
+		}
+
+		if program.String() != tt.expectedProgram {
+			t.Errorf("program.String() is not %q. got = %q", tt.expectedProgram, program.String())
+		}
+	}
+}
+
+func TestMisplacedLoopControlErrors(t *testing.T) {
+	tests := []string{
+		"break;",
+		"continue;",
+		"try { break; } finally { 1; }",
+		"while (x) { break outer; }",
+		"outer: while (x) { outer: while (y) { 1; } }",
+		"outer: 5;",
+	}
+
+	for _, input := range tests {
+		l := lexer.NewLexer(input)
+		p := NewParser(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("parser has no errors for %q", input)
+		}
+	}
+}
+
+func TestBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true;", true},
+		{"false;", false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		boolean, ok := stmt.Expression.(*ast.Boolean)
+		if !ok {
+			t.Fatalf("stmt.Expression is not *ast.Boolean. got = %T", stmt.Expression)
+		}
+
+		if boolean.Value != tt.expected {
+			t.Errorf("boolean.Value is not %t. got = %t", tt.expected, boolean.Value)
+		}
+	}
+}
+
+func TestIfExpression(t *testing.T) {
+	tests := []struct {
+		input       string
+		condition   string
+		alternative bool
+	}{
+		{"if (x < y) { x }", "(x < y)", false},
+		{"if (x) { x } else { y }", "x", true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		expression, ok := stmt.Expression.(*ast.IfExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not *ast.IfExpression. got = %T", stmt.Expression)
+		}
+
+		if expression.Condition.String() != tt.condition {
+			t.Errorf("expression.Condition is not %q. got = %q", tt.condition, expression.Condition.String())
+		}
+
+		if len(expression.Consequence.Statements) != 1 {
+			t.Errorf("expression.Consequence does not contain 1 statement. got = %d", len(expression.Consequence.Statements))
+		}
+
+		if (expression.Alternative != nil) != tt.alternative {
+			t.Errorf("expression.Alternative is not as expected. got = %v", expression.Alternative)
+		}
+	}
+}
+
+func TestFunctionLiteral(t *testing.T) {
+	tests := []struct {
+		input      string
+		parameters []string
+	}{
+		{"fn() { 1; };", []string{}},
+		{"fn(x) { x; };", []string{"x"}},
+		{"fn(x, y) { x + y; };", []string{"x", "y"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not *ast.FunctionLiteral. got = %T", stmt.Expression)
+		}
+
+		if len(function.Parameters) != len(tt.parameters) {
+			t.Fatalf("function.Parameters does not contain %d parameters. got = %d", len(tt.parameters), len(function.Parameters))
+		}
+
+		for i, parameter := range tt.parameters {
+			if function.Parameters[i].Literal != parameter {
+				t.Errorf("function.Parameters[%d] is not %q. got = %q", i, parameter, function.Parameters[i].Literal)
+			}
+		}
+
+		if len(function.Body.Statements) != 1 {
+			t.Errorf("function.Body does not contain 1 statement. got = %d", len(function.Body.Statements))
+		}
+	}
+}
+
+func TestCallExpression(t *testing.T) {
+	l := lexer.NewLexer("add(1, 2 * 3, x);")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.CallExpression. got = %T", stmt.Expression)
+	}
+
+	if call.Function.String() != "add" {
+		t.Errorf("call.Function is not %q. got = %q", "add", call.Function.String())
+	}
+
+	if len(call.Arguments) != 3 {
+		t.Fatalf("call.Arguments does not contain 3 arguments. got = %d", len(call.Arguments))
+	}
+
+	testIntegerLiteral(t, call.Arguments[0], 1)
+	if call.Arguments[1].String() != "(2 * 3)" {
+		t.Errorf("call.Arguments[1] is not %q. got = %q", "(2 * 3)", call.Arguments[1].String())
+	}
+}
+
+func TestLoopControlInFunctionError(t *testing.T) {
+	l := lexer.NewLexer("while (x) { fn() { break; }; }")
+	p := NewParser(l)
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Errorf("parser has no errors for a break inside a function inside a loop")
+	}
+}
+
+func TestOperatorPrecedenceParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-a * b", "((-a) * b)"},
+		{"-1.5 * 2e3 + x", "(((-1.5) * 2e3) + x)"},
+		{"!-a", "(!(-a))"},
+		{"a + b - c", "((a + b) - c)"},
+		{"a + b * c - d / e", "((a + (b * c)) - (d / e))"},
+		{"5 > 4 == 3 < 4", "((5 > 4) == (3 < 4))"},
+		{"(a + b) * c", "((a + b) * c)"},
+		{"-(5 + 5)", "(-(5 + 5))"},
+		{"a - (b - c)", "(a - (b - c))"},
+		{"(a + b)[0]", "((a + b)[0])"},
+		{"a + add(b * c) + d", "((a + add((b * c))) + d)"},
+		{"add(a, b, 1, 2 * 3, add(4))", "add(a, b, 1, (2 * 3), add(4))"},
+		{"-f(x)[0]", "(-(f(x)[0]))"},
+		{"!true == false", "((!true) == false)"},
+		{"[1, 2 * 3][0] + [][a]", "(([1, (2 * 3)][0]) + ([][a]))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() is not %q. got = %q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestAssignExpression(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedOperator string
+		expectedProgram  string
+	}{
+		{"x = 5;", "=", "(x = 5)"},
+		{"x = y = 5;", "=", "(x = (y = 5))"},
+		{"x += 1 + 2 * 3;", "+=", "(x += (1 + (2 * 3)))"},
+		{"x -= y == 1;", "-=", "(x -= (y == 1))"},
+		{"x *= y /= 2;", "*=", "(x *= (y /= 2))"},
+		{"arr[i] = v;", "=", "((arr[i]) = v)"},
+		{"arr[i + 1] /= 2;", "/=", "((arr[(i + 1)]) /= 2)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input)
+		p := NewParser(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got = %d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got = %T", program.Statements[0])
+		}
+
+		assign, ok := stmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.AssignExpression. got = %T", stmt.Expression)
+		}
+
+		if assign.Operator != tt.expectedOperator {
+			t.Errorf("assign.Operator is not %q. got = %q", tt.expectedOperator, assign.Operator)
+		}
+
+		if program.String() != tt.expectedProgram {
+			t.Errorf("program.String() is not %q. got = %q", tt.expectedProgram, program.String())
+		}
+	}
+}
+
+func TestInvalidAssignTargetErrors(t *testing.T) {
+	tests := []string{
+		"5 = x;",
+		"x + y = 1;",
+		"-x += 1;",
+	}
+
+	for _, input := range tests {
+		l := lexer.NewLexer(input)
+		p := NewParser(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("parser has no errors for %q", input)
+		}
+	}
+}
+
 func testIntegerLiteral(t *testing.T, integerLiteral ast.Expression, value int64) bool {
 	integer, ok := integerLiteral.(*ast.IntegerLiteral)
 	if !ok {
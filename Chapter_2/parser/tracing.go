@@ -0,0 +1,75 @@
+package parser
+
+// Tracing of the parser, to debug precedence problems
+//
+// When a trace writer is set, the parser logs each entry and exit of
+// parseExpression, the prefix and infix parse functions and the statement parsers,
+// indented by nesting, with the current token and precedence
+// When it is not set, every trace point is a single nil check
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// The names of the precedence levels, for the trace
+var precedenceNames = map[int]string{
+	LOWEST:      "LOWEST",
+	ASSIGNMENT:  "ASSIGNMENT",
+	EQUALS:      "EQUALS",
+	LESSGREATER: "LESSGREATER",
+	SUM:         "SUM",
+	PRODUCT:     "PRODUCT",
+	PREFIX:      "PREFIX",
+	CALL:        "CALL",
+	INDEX:       "INDEX",
+}
+
+// A function to turn on tracing to a writer, or off with nil
+func (p *Parser) SetTrace(w io.Writer) {
+	p.traceOut = w
+	p.traceLevel = 0
+}
+
+// A function to log the entry of a parse function and indent what follows
+// It returns the name to pass to untrace
+func (p *Parser) trace(name string) string {
+	p.tracePrint("BEGIN " + name)
+	p.traceLevel += 1
+	return name
+}
+
+// A function to log the exit of a parse function
+func (p *Parser) untrace(name string) {
+	p.traceLevel -= 1
+	p.tracePrint("END " + name)
+}
+
+func (p *Parser) tracePrint(msg string) {
+	fmt.Fprintf(p.traceOut, "%s%s [cur %s %q at %s, peek %s %q]\n",
+		strings.Repeat("\t", p.traceLevel), msg,
+		p.curToken.Type, p.curToken.Literal, p.curToken.Position(),
+		p.peekToken.Type, p.peekToken.Literal)
+}
+
+// A function to return the name of a precedence level for the trace
+func precedenceName(precedence int) string {
+	if name, ok := precedenceNames[precedence]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", precedence)
+}
+
+// A function to return the name of a prefix or infix parse function for the trace
+// e.g. "parseIntegerLiteral"
+func parseFnName(fn any) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
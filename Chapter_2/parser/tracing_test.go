@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"Chapter_2/lexer"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	expected := []string{
+		"BEGIN parseExpressionStatement",
+		"\tBEGIN parseExpression(LOWEST)",
+		"\t\tBEGIN prefix parseIntegerLiteral",
+		"\t\tEND prefix parseIntegerLiteral",
+		"\t\tBEGIN infix parseInfixExpression(SUM)",
+		"\t\t\tBEGIN parseExpression(SUM)",
+		"\t\t\t\tBEGIN prefix parseIntegerLiteral",
+		"\t\t\t\tEND prefix parseIntegerLiteral",
+		"\t\t\t\tBEGIN infix parseInfixExpression(PRODUCT)",
+		"\t\t\t\t\tBEGIN parseExpression(PRODUCT)",
+		"\t\t\t\t\t\tBEGIN prefix parseIntegerLiteral",
+		"\t\t\t\t\t\tEND prefix parseIntegerLiteral",
+		"\t\t\t\t\tEND parseExpression(PRODUCT)",
+		"\t\t\t\tEND infix parseInfixExpression(PRODUCT)",
+		"\t\t\tEND parseExpression(SUM)",
+		"\t\tEND infix parseInfixExpression(SUM)",
+		"\tEND parseExpression(LOWEST)",
+		"END parseExpressionStatement",
+	}
+
+	var out bytes.Buffer
+	l := lexer.NewLexer("1 + 2 * 3;")
+	p := NewParser(l)
+	p.SetTrace(&out)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("trace has %d lines, expected %d. got =\n%s", len(lines), len(expected), out.String())
+	}
+
+	for i, line := range lines {
+		// Drop the token details
+		name, _, _ := strings.Cut(line, " [")
+		if name != expected[i] {
+			t.Errorf("Failed at [%d] - expected %q, got %q", i, expected[i], name)
+		}
+	}
+
+	if !strings.HasSuffix(lines[4], `[cur + "+" at 1:3, peek INT "2"]`) {
+		t.Errorf("trace line does not show the current token. got = %q", lines[4])
+	}
+}
+
+func TestTraceStatements(t *testing.T) {
+	var out bytes.Buffer
+	l := lexer.NewLexer("while (x) { let y = 1; break; }")
+	p := NewParser(l)
+	p.SetTrace(&out)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	for _, name := range []string{"parseWhileStatement", "parseBlockStatement", "parseLetStatement", "parseBreakStatement"} {
+		if !strings.Contains(out.String(), "BEGIN "+name) || !strings.Contains(out.String(), "END "+name) {
+			t.Errorf("trace does not contain %s. got =\n%s", name, out.String())
+		}
+	}
+}
+
+func TestTraceOff(t *testing.T) {
+	var out bytes.Buffer
+	l := lexer.NewLexer("1 + 2;")
+	p := NewParser(l)
+	p.SetTrace(&out)
+	p.SetTrace(nil)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if out.Len() != 0 {
+		t.Errorf("trace was written while tracing is off. got = %q", out.String())
+	}
+}
@@ -0,0 +1,180 @@
+package repl
+
+// Syntax highlighting of the input and the output of the REPL, with ANSI colors
+
+import (
+	"Chapter_2/lexer"
+	"Chapter_2/object"
+	"Chapter_2/parser"
+	"Chapter_2/token"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// When to color, as in --color=auto|always|never
+type ColorMode string
+
+const (
+	ColorAuto   ColorMode = "auto"   // color if the output is a terminal and NO_COLOR is not set
+	ColorAlways ColorMode = "always" // color even if the output is not a terminal or NO_COLOR is set
+	ColorNever  ColorMode = "never"
+)
+
+// A function to parse the value of --color
+func ParseColorMode(value string) (ColorMode, error) {
+	switch mode := ColorMode(value); mode {
+	case ColorAuto, ColorAlways, ColorNever:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid color mode %q, expected auto, always or never", value)
+}
+
+// A function to report whether to color the output written to a file
+// https://no-color.org: a non-empty NO_COLOR turns colors off, unless they are asked for
+func UseColor(mode ColorMode, out *os.File) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return IsTerminal(out)
+}
+
+// The ANSI escape codes of the colors
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+)
+
+// The colors of the kinds of tokens, and of values
+const (
+	keywordColor  = colorMagenta
+	numberColor   = colorCyan
+	stringColor   = colorGreen
+	operatorColor = colorYellow
+	commentColor  = colorGray
+	builtinColor  = colorBlue
+	errorColor    = colorRed
+)
+
+func colorize(color string, text string) string {
+	return color + text + colorReset
+}
+
+// A function to return the color of a token type, "" for no color
+func tokenColor(tokenType token.TokenType) string {
+	switch tokenType {
+	case token.INT, token.FLOAT:
+		return numberColor
+	case token.STRING:
+		return stringColor
+	case token.ILLEGAL:
+		return errorColor
+	case token.VARIABLE, token.EOF,
+		token.COMMA, token.SEMICOLON, token.COLON,
+		token.SLBRACKET, token.SRBRACKET, token.RLBRACKET, token.RRBRACKET, token.PLBRACKET, token.PRBRACKET:
+		return ""
+	}
+
+	for _, keyword := range token.Keywords {
+		if tokenType == keyword {
+			return keywordColor
+		}
+	}
+	if tokenType == token.EXCLAMATION || parser.Precedence(tokenType) > parser.LOWEST {
+		return operatorColor
+	}
+	return ""
+}
+
+// A function to color the tokens and the comments of some source
+// The text is unchanged apart from the escape codes, so the cursor can be placed by counting characters
+func highlight(source string) string {
+	// The offsets the lines start at, to turn positions into offsets
+	lineStarts := []int{0}
+	for i, char := range []byte(source) {
+		if char == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	type span struct {
+		start, end int
+		color      string
+	}
+	spans := []span{}
+	add := func(tok token.Token, color string) {
+		if color == "" || tok.Line < 1 || tok.Line > len(lineStarts) {
+			return
+		}
+		start := lineStarts[tok.Line-1] + tok.Column - 1
+		end := start + len(tok.Literal)
+		// The literal of a string does not include its quotes
+		if tok.Type == token.STRING {
+			end += 2
+		}
+		spans = append(spans, span{start, end, color})
+	}
+
+	l := lexer.NewLexer(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		add(tok, tokenColor(tok.Type))
+	}
+	for _, comment := range l.Comments() {
+		add(comment, commentColor)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var out strings.Builder
+	offset := 0
+	for _, span := range spans {
+		if span.start < offset || span.end > len(source) {
+			continue
+		}
+		out.WriteString(source[offset:span.start])
+		out.WriteString(colorize(span.color, source[span.start:span.end]))
+		offset = span.end
+	}
+	out.WriteString(source[offset:])
+
+	return out.String()
+}
+
+// A function to return the inspection string of a value, colored by its type
+// Strings are quoted, so "1" and 1 look different even without the colors
+func colorInspect(value object.Object) string {
+	switch value := value.(type) {
+	case *object.Integer, *object.Float:
+		return colorize(numberColor, value.Inspect())
+	case *object.String:
+		return colorize(stringColor, strconv.Quote(value.Value))
+	case *object.Boolean, *object.Null:
+		return colorize(keywordColor, value.Inspect())
+	case *object.Error:
+		return colorize(errorColor, value.Inspect())
+	case *object.Builtin:
+		return colorize(builtinColor, value.Inspect())
+	case *object.Function:
+		return highlight(value.Inspect())
+	case *object.Array:
+		elements := []string{}
+		for _, element := range value.Elements {
+			elements = append(elements, colorInspect(element))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+	return value.Inspect()
+}
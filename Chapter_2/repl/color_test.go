@@ -0,0 +1,27 @@
+package repl
+
+import (
+	"Chapter_2/object"
+	"testing"
+)
+
+func TestColorInspect(t *testing.T) {
+	tests := []struct {
+		value    object.Object
+		expected string
+	}{
+		{&object.String{Value: "hi"}, stringColor + `"hi"` + colorReset},
+		{&object.String{Value: "a \"b\"\n"}, stringColor + `"a \"b\"\n"` + colorReset},
+		{&object.Integer{Value: 1}, numberColor + "1" + colorReset},
+		{
+			&object.Array{Elements: []object.Object{&object.String{Value: "1"}, &object.Integer{Value: 1}}},
+			"[" + stringColor + `"1"` + colorReset + ", " + numberColor + "1" + colorReset + "]",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := colorInspect(tt.value); got != tt.expected {
+			t.Errorf("colorInspect(%s) is not %q. got = %q", tt.value.Inspect(), tt.expected, got)
+		}
+	}
+}
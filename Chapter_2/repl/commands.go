@@ -0,0 +1,202 @@
+package repl
+
+// The colon commands of the REPL, e.g. ":env" or ":load rules.mk"
+//
+// Embedding applications add their own with Register
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/object"
+	"Chapter_2/parser"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A Command contains
+type Command struct {
+	Name  string                           // the name, without the colon
+	Usage string                           // the arguments, e.g. "<file.mk>", for :help
+	Help  string                           // what the command does, for :help
+	Run   func(r *REPL, args string) error // the function run with the rest of the line
+}
+
+// A function to add a command
+// A command with the name of another one replaces it
+func (r *REPL) Register(command Command) {
+	r.commands[command.Name] = command
+}
+
+// A function to run a command line, e.g. ":load rules.mk"
+func (r *REPL) RunCommand(line string) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+
+	command, ok := r.commands[name]
+	if !ok {
+		fmt.Fprintf(r.Out, "unknown command :%s, type :help for the commands\n", name)
+		return
+	}
+	if err := command.Run(r, strings.TrimSpace(args)); err != nil {
+		fmt.Fprintf(r.Out, ":%s: %s\n", name, err)
+	}
+}
+
+// The commands of every REPL
+var builtinCommands = []Command{
+	{
+		Name:  "tokens",
+		Usage: "[src]",
+		Help:  "print the tokens of src, or switch token mode on and off",
+		Run: func(r *REPL, args string) error {
+			if args != "" {
+				printTokens(args, r.Out)
+				return nil
+			}
+			r.Tokens = !r.Tokens
+			if r.Tokens {
+				fmt.Fprintln(r.Out, "token mode on, type :tokens to evaluate again")
+			} else {
+				fmt.Fprintln(r.Out, "token mode off")
+			}
+			return nil
+		},
+	},
+	{
+		Name:  "ast",
+		Usage: "[dot|sexp] <src>",
+		Help:  "print the parse tree of src as a program, a Graphviz DOT graph or an S-expression",
+		Run: func(r *REPL, args string) error {
+			mode, source, _ := strings.Cut(args, " ")
+			if mode != "dot" && mode != "sexp" {
+				mode, source = "", args
+			}
+
+			p := parser.NewParser(lexer.NewLexer(source))
+			program := p.ParseProgram()
+			if len(p.Errors()) > 0 {
+				r.printParserErrors(source, p.Errors())
+				return nil
+			}
+
+			switch mode {
+			case "dot":
+				fmt.Fprint(r.Out, ast.DOT(program))
+			case "sexp":
+				fmt.Fprint(r.Out, ast.SExpression(program))
+			default:
+				fmt.Fprintln(r.Out, program.String())
+			}
+			return nil
+		},
+	},
+	{
+		Name: "env",
+		Help: "list the bindings of the session",
+		Run: func(r *REPL, args string) error {
+			for _, name := range r.Env.Names() {
+				value, _ := r.Env.Get(name)
+				fmt.Fprintf(r.Out, "%s = %s\n", name, r.inspect(value))
+			}
+			return nil
+		},
+	},
+	{
+		Name:  "load",
+		Usage: "<file.mk>",
+		Help:  "evaluate a file in the session",
+		Run: func(r *REPL, args string) error {
+			if args == "" {
+				return fmt.Errorf("missing file")
+			}
+			source, err := os.ReadFile(args)
+			if err != nil {
+				return err
+			}
+
+			p := parser.NewParser(lexer.NewLexer(string(source)))
+			program := p.ParseProgram()
+			if len(p.Errors()) > 0 {
+				for _, msg := range p.Errors() {
+					fmt.Fprintf(r.Out, "%s:%s\n", args, msg)
+				}
+				return nil
+			}
+
+			// The errors of the file, and of the functions it defines, point into it
+			r.Env.SetFile(args)
+			_, evalErr := r.evalProgram(program)
+			r.Env.SetFile("")
+			if evalErr != nil {
+				r.printError(evalErr)
+				return nil
+			}
+			// The source is saved rather than the command, so :save does not depend on the file
+			r.History = append(r.History, strings.TrimRight(string(source), "\n"))
+			return nil
+		},
+	},
+	{
+		Name:  "save",
+		Usage: "<file.mk>",
+		Help:  "write the inputs of the session to a file",
+		Run: func(r *REPL, args string) error {
+			if args == "" {
+				return fmt.Errorf("missing file")
+			}
+
+			var content strings.Builder
+			for _, input := range r.History {
+				content.WriteString(input + "\n")
+			}
+			if err := os.WriteFile(args, []byte(content.String()), 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(r.Out, "saved %d inputs to %s\n", len(r.History), args)
+			return nil
+		},
+	},
+	{
+		Name: "reset",
+		Help: "forget the bindings, the inputs and the imported modules of the session",
+		Run: func(r *REPL, args string) error {
+			r.Env = object.NewEnvironment()
+			r.History = nil
+			return nil
+		},
+	},
+	{
+		Name:  "time",
+		Usage: "<src>",
+		Help:  "evaluate src and print how long it took",
+		Run: func(r *REPL, args string) error {
+			start := time.Now()
+			r.Eval(args)
+			fmt.Fprintf(r.Out, "took %s\n", time.Since(start))
+			return nil
+		},
+	},
+	{
+		Name: "help",
+		Help: "list the commands",
+		Run: func(r *REPL, args string) error {
+			names := []string{}
+			for name := range r.commands {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				command := r.commands[name]
+				usage := ":" + name
+				if command.Usage != "" {
+					usage += " " + command.Usage
+				}
+				fmt.Fprintf(r.Out, "  %-24s %s\n", usage, command.Help)
+			}
+			return nil
+		},
+	},
+}
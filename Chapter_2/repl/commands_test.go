@@ -0,0 +1,155 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{":tokens 1;\n", ">> {Type:INT Literal:1 Line:1 Column:1}\n{Type:; Literal:; Line:1 Column:2}\n>> "},
+		{":tokens\n1\n:tokens\n1\n", ">> token mode on, type :tokens to evaluate again\n>> {Type:INT Literal:1 Line:1 Column:1}\n>> token mode off\n>> 1\n>> "},
+		{":ast 1 + 2 * 3\n", ">> (1 + (2 * 3))\n>> "},
+		{":ast sexp -1\n", ">> (Program\n  (ExpressionStatement \"-\"\n    (PrefixExpression \"-\"\n      (IntegerLiteral \"1\"))))\n>> "},
+		{
+			":ast let = 1;\n",
+			">> 1:5: Expect the next token to be VAR, got = instead\n    let = 1;\n        ^\n" +
+				"1:5: no prefix parse function for = found\n    let = 1;\n        ^\n>> ",
+		},
+		{"let b = 2;\nlet a = \"x\";\n:env\n", ">> >> >> a = x\nb = 2\n>> "},
+		{"let a = 1;\n:reset\n:env\na\n", ">> >> >> >> ERROR: undefined variable a\n\nmain()\n\t<input>:1:1\n>> "},
+		{"  :env  \n", ">> >> "},
+		{":bogus\n", ">> unknown command :bogus, type :help for the commands\n>> "},
+		{":load\n", ">> :load: missing file\n>> "},
+		{":save\n", ">> :save: missing file\n>> "},
+	}
+
+	for _, tt := range tests {
+		if got := run(tt.input); got != tt.expected {
+			t.Errorf("the REPL did not print %q for %q. got = %q", tt.expected, tt.input, got)
+		}
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	out := run(":help\n")
+	for _, name := range []string{"tokens", "ast", "env", "load", "save", "reset", "time", "help"} {
+		if !strings.Contains(out, "  :"+name) {
+			t.Errorf(":help does not list :%s. got = %q", name, out)
+		}
+	}
+}
+
+func TestTimeCommand(t *testing.T) {
+	out := run(":time 1 + 2\n")
+	if !strings.HasPrefix(out, ">> 3\ntook ") {
+		t.Errorf(":time did not print the value and the time. got = %q", out)
+	}
+}
+
+func TestLoadAndSave(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "double.mk")
+	if err := os.WriteFile(file, []byte("let double = fn(x) { x * 2 };\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	saved := filepath.Join(dir, "session.mk")
+
+	// Only the inputs evaluated without an error are saved
+	input := fmt.Sprintf(":load %s\ndouble(2)\n1 / 0\nlet = 1;\nlet x = double(3);\n:save %s\n", file, saved)
+	out := run(input)
+	if !strings.Contains(out, ">> 4\n") || !strings.Contains(out, "saved 3 inputs to "+saved) {
+		t.Fatalf("the REPL did not load and save the session. got = %q", out)
+	}
+
+	content, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "let double = fn(x) { x * 2 };\ndouble(2)\nlet x = double(3);\n"
+	if string(content) != expected {
+		t.Errorf("the saved session is not %q. got = %q", expected, content)
+	}
+
+	// The saved session runs again
+	if out := run(fmt.Sprintf(":load %s\nx\n", saved)); out != ">> >> 6\n>> " {
+		t.Errorf("the saved session did not load. got = %q", out)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.mk")
+	if err := os.WriteFile(bad, []byte("let = 1;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	failing := filepath.Join(dir, "failing.mk")
+	if err := os.WriteFile(failing, []byte("let x = 1;\n1 / 0;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			":load " + bad + "\n",
+			">> " + bad + ":1:5: Expect the next token to be VAR, got = instead\n" + bad + ":1:5: no prefix parse function for = found\n>> ",
+		},
+		{":load " + failing + "\n", ">> ERROR: division by zero\n\nmain()\n\t" + failing + ":2:3\n>> "},
+		{":load " + filepath.Join(dir, "missing.mk") + "\n", ">> :load: open " + filepath.Join(dir, "missing.mk") + ": no such file or directory\n>> "},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		r := New(&out)
+		r.HistoryFile = ""
+		r.Run(strings.NewReader(tt.input))
+
+		if out.String() != tt.expected {
+			t.Errorf("the REPL did not print %q for %q. got = %q", tt.expected, tt.input, out.String())
+		}
+		if len(r.History) != 0 {
+			t.Errorf("a file that failed to load is in the history. got = %q", r.History)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out)
+	r.HistoryFile = ""
+	r.Register(Command{
+		Name: "greet",
+		Help: "greet someone",
+		Run: func(r *REPL, args string) error {
+			if args == "" {
+				return fmt.Errorf("missing name")
+			}
+			fmt.Fprintf(r.Out, "hello %s\n", args)
+			return nil
+		},
+	})
+	// A command replaces the one with its name
+	r.Register(Command{
+		Name: "env",
+		Help: "count the bindings of the session",
+		Run: func(r *REPL, args string) error {
+			fmt.Fprintf(r.Out, "%d bindings\n", len(r.Env.Names()))
+			return nil
+		},
+	})
+	r.Run(strings.NewReader(":greet monkey\n:greet\nlet x = 1;\n:env\n"))
+
+	expected := ">> hello monkey\n>> :greet: missing name\n>> >> 1 bindings\n>> "
+	if out.String() != expected {
+		t.Errorf("the REPL did not print %q. got = %q", expected, out.String())
+	}
+}
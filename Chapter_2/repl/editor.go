@@ -0,0 +1,417 @@
+package repl
+
+// Reading the lines of the REPL
+//
+// On a Linux terminal the lines are edited in raw mode: arrow keys, Home/End,
+// history browsing, Ctrl-R reverse search and tab completion
+// Otherwise, e.g. from a pipe, they are read with a bufio.Scanner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// The number of history lines kept
+const maxHistory = 1000
+
+// The error of a line abandoned with Ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+// A lineReader reads the lines of the REPL
+// ReadLine returns io.EOF at the end of the input, or for Ctrl-D on an empty line
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// A function to report whether a file is a terminal
+func IsTerminal(file *os.File) bool {
+	return isTerminal(file)
+}
+
+// A function to return the line reader for some input
+// It is a line editor if the input is a terminal the lines can be edited on, and a scanner otherwise
+func (r *REPL) newLineReader(in io.Reader) lineReader {
+	if file, ok := in.(*os.File); ok && canEditLines && IsTerminal(file) {
+		editor := &lineEditor{
+			fd:          int(file.Fd()),
+			makeRaw:     makeRaw,
+			in:          bufio.NewReader(file),
+			out:         r.Out,
+			historyFile: r.HistoryFile,
+			complete:    r.complete,
+		}
+		if r.Color {
+			editor.highlight = highlight
+		}
+		editor.loadHistory()
+		return editor
+	}
+	return &scannerReader{in: in, out: r.Out}
+}
+
+// A scannerReader reads plain lines
+type scannerReader struct {
+	in      io.Reader
+	out     io.Writer
+	scanner *bufio.Scanner
+}
+
+func (s *scannerReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(s.out, prompt)
+
+	if s.scanner == nil {
+		s.scanner = bufio.NewScanner(s.in)
+	}
+	if !s.scanner.Scan() {
+		// A scanner stops at the end of its input, the next line needs a new one
+		// e.g. on a terminal in cooked mode, after a Ctrl-D
+		s.scanner = nil
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+// A lineEditor edits lines on a terminal
+type lineEditor struct {
+	fd          int
+	makeRaw     func(fd int) (func(), error) // the function putting the terminal in raw mode
+	in          *bufio.Reader
+	out         io.Writer
+	history     []string                   // the lines read, oldest first
+	historyFile string                     // the file the history is kept in, "" to not keep it
+	complete    func(word string) []string // the completions of the word before the cursor
+	highlight   func(line string) string   // the function coloring the line, nil for no colors
+}
+
+// The key codes of the control keys
+const (
+	ctrlA     = 1
+	ctrlB     = 2
+	ctrlC     = 3
+	ctrlD     = 4
+	ctrlE     = 5
+	ctrlF     = 6
+	ctrlG     = 7
+	ctrlH     = 8
+	tab       = 9
+	lineFeed  = 10
+	ctrlK     = 11
+	enter     = 13
+	ctrlN     = 14
+	ctrlP     = 16
+	ctrlR     = 18
+	ctrlU     = 21
+	escape    = 27
+	backspace = 127
+)
+
+func (e *lineEditor) ReadLine(prompt string) (string, error) {
+	restore, err := e.makeRaw(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	fmt.Fprint(e.out, prompt)
+
+	line := []rune{}
+	cursor := 0
+	// The history line shown, len(e.history) for the line being edited
+	historyIndex := len(e.history)
+	// The line being edited, while the history is browsed
+	edited := []rune{}
+
+	showHistory := func(index int) {
+		if historyIndex == len(e.history) {
+			edited = line
+		}
+		historyIndex = index
+		if index == len(e.history) {
+			line = edited
+		} else {
+			line = []rune(e.history[index])
+		}
+		cursor = len(line)
+	}
+
+	for {
+		key, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case enter, lineFeed:
+			fmt.Fprint(e.out, "\n")
+			e.addHistory(string(line))
+			return string(line), nil
+
+		case ctrlC:
+			fmt.Fprint(e.out, "^C\n")
+			return "", errInterrupted
+
+		case ctrlD:
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+			if cursor < len(line) {
+				line = append(line[:cursor:cursor], line[cursor+1:]...)
+			}
+
+		case backspace, ctrlH:
+			if cursor > 0 {
+				line = append(line[:cursor-1:cursor-1], line[cursor:]...)
+				cursor -= 1
+			}
+
+		case ctrlA:
+			cursor = 0
+		case ctrlE:
+			cursor = len(line)
+		case ctrlB:
+			cursor = max(cursor-1, 0)
+		case ctrlF:
+			cursor = min(cursor+1, len(line))
+		case ctrlK:
+			line = line[:cursor]
+		case ctrlU:
+			line = line[cursor:]
+			cursor = 0
+
+		case ctrlP:
+			if historyIndex > 0 {
+				showHistory(historyIndex - 1)
+			}
+		case ctrlN:
+			if historyIndex < len(e.history) {
+				showHistory(historyIndex + 1)
+			}
+
+		case ctrlR:
+			found, submit, err := e.search(line)
+			if err != nil {
+				return "", err
+			}
+			line, cursor = found, len(found)
+			if submit {
+				e.refresh(prompt, line, cursor)
+				fmt.Fprint(e.out, "\n")
+				e.addHistory(string(line))
+				return string(line), nil
+			}
+
+		case tab:
+			line, cursor = e.completeWord(prompt, line, cursor)
+
+		case escape:
+			switch e.readEscape() {
+			case "A": // up
+				if historyIndex > 0 {
+					showHistory(historyIndex - 1)
+				}
+			case "B": // down
+				if historyIndex < len(e.history) {
+					showHistory(historyIndex + 1)
+				}
+			case "C": // right
+				cursor = min(cursor+1, len(line))
+			case "D": // left
+				cursor = max(cursor-1, 0)
+			case "H", "1~", "7~": // home
+				cursor = 0
+			case "F", "4~", "8~": // end
+				cursor = len(line)
+			case "3~": // delete
+				if cursor < len(line) {
+					line = append(line[:cursor:cursor], line[cursor+1:]...)
+				}
+			}
+
+		default:
+			if unicode.IsPrint(key) {
+				line = append(line[:cursor:cursor], append([]rune{key}, line[cursor:]...)...)
+				cursor += 1
+			}
+		}
+
+		e.refresh(prompt, line, cursor)
+	}
+}
+
+// A function to redraw the line and put the cursor back
+func (e *lineEditor) refresh(prompt string, line []rune, cursor int) {
+	text := string(line)
+	if e.highlight != nil {
+		text = e.highlight(text)
+	}
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, text)
+	if back := len(line) - cursor; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// A function to read the rest of an escape sequence, e.g. "[A" for the up arrow
+// It returns the sequence without its "[" or "O", e.g. "A" or "3~"
+func (e *lineEditor) readEscape() string {
+	key, _, err := e.in.ReadRune()
+	if err != nil || (key != '[' && key != 'O') {
+		return ""
+	}
+
+	sequence := []rune{}
+	for {
+		key, _, err := e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		sequence = append(sequence, key)
+		// The sequence ends with a letter or "~"
+		if key >= 0x40 && key <= 0x7e {
+			return string(sequence)
+		}
+	}
+}
+
+// A function to search the history backwards for the lines containing a query, like Ctrl-R in bash
+// Ctrl-R again finds an older line, Enter submits the line found,
+// Ctrl-G or Ctrl-C give back the line being edited, and any other key edits the line found
+func (e *lineEditor) search(line []rune) ([]rune, bool, error) {
+	query := []rune{}
+	index := len(e.history)
+	found := line
+
+	find := func(from int) {
+		for i := min(from, len(e.history)-1); i >= 0; i-- {
+			if strings.Contains(e.history[i], string(query)) {
+				index, found = i, []rune(e.history[i])
+				return
+			}
+		}
+	}
+
+	for {
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), string(found))
+
+		key, _, err := e.in.ReadRune()
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch key {
+		case enter, lineFeed:
+			return found, true, nil
+		case ctrlG, ctrlC:
+			return line, false, nil
+		case ctrlR:
+			find(index - 1)
+		case backspace, ctrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				find(len(e.history) - 1)
+			}
+		case escape:
+			e.readEscape()
+			return found, false, nil
+		default:
+			if !unicode.IsPrint(key) {
+				return found, false, nil
+			}
+			query = append(query, key)
+			find(index)
+		}
+	}
+}
+
+// A function to complete the word before the cursor
+// A single completion is inserted, several ones are completed to their common prefix,
+// or listed if they have no longer one
+func (e *lineEditor) completeWord(prompt string, line []rune, cursor int) ([]rune, int) {
+	start := cursor
+	for start > 0 && isWordRune(line[start-1]) {
+		start -= 1
+	}
+	// A command is completed with its colon
+	if start == 1 && line[0] == ':' {
+		start = 0
+	}
+
+	word := string(line[start:cursor])
+	if word == "" || e.complete == nil {
+		return line, cursor
+	}
+
+	completions := e.complete(word)
+	if len(completions) == 0 {
+		fmt.Fprint(e.out, "\a")
+		return line, cursor
+	}
+
+	prefix := completions[0]
+	for _, completion := range completions[1:] {
+		for !strings.HasPrefix(completion, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	if len(prefix) > len(word) {
+		insert := []rune(prefix[len(word):])
+		line = append(line[:cursor:cursor], append(insert, line[cursor:]...)...)
+		return line, cursor + len(insert)
+	}
+
+	if len(completions) > 1 {
+		fmt.Fprintf(e.out, "\n%s\n", strings.Join(completions, "  "))
+		e.refresh(prompt, line, cursor)
+	}
+	return line, cursor
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// A function to read the history file, keeping its last lines
+func (e *lineEditor) loadHistory() {
+	if e.historyFile == "" {
+		return
+	}
+	content, err := os.ReadFile(e.historyFile)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+		// Trim the file too, so it does not grow forever
+		os.WriteFile(e.historyFile, []byte(strings.Join(e.history, "\n")+"\n"), 0o600)
+	}
+}
+
+// A function to add a line to the history and to the history file
+// Blank lines and repeats of the last line are not added
+func (e *lineEditor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+
+	if e.historyFile == "" {
+		return
+	}
+	file, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
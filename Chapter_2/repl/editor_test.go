@@ -0,0 +1,191 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A function to leave the terminal as it is, the tests have none
+func noRaw(fd int) (func(), error) {
+	return func() {}, nil
+}
+
+// A function to return a line editor reading some keys
+func newTestEditor(keys string, history ...string) *lineEditor {
+	r := New(&bytes.Buffer{})
+	r.Eval("let double = fn(x) { x * 2 };")
+	return &lineEditor{
+		makeRaw:  noRaw,
+		in:       bufio.NewReader(strings.NewReader(keys)),
+		out:      &bytes.Buffer{},
+		history:  history,
+		complete: r.complete,
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"abc\r", "abc"},
+		{"abc\n", "abc"},
+		// Moving the cursor
+		{"ac\x1b[Db\r", "abc"},
+		{"ac\x02b\r", "abc"},
+		{"bc\x01a\x05d\r", "abcd"},
+		{"bc\x1b[Ha\x1b[Fd\r", "abcd"},
+		{"ab\x02\x02\x06\x1b[Cc\r", "abc"},
+		// Deleting
+		{"abc\x7f\r", "ab"},
+		{"abc\x08\r", "ab"},
+		{"abc\x01\x1b[3~\r", "bc"},
+		{"abc\x01\x04\r", "bc"},
+		{"abc\x02\x0b\r", "ab"},
+		{"abc\x02\x15\r", "c"},
+		// Browsing the history
+		{"\x1b[A\r", "two"},
+		{"\x1b[A\x1b[A\r", "one"},
+		{"\x1b[A\x1b[A\x1b[A\r", "one"},
+		{"\x10\x10\x0e\r", "two"},
+		{"x\x1b[A\x1b[B\r", "x"},
+		{"\x1b[Ax\r", "twox"},
+		// Searching the history
+		{"\x12on\r", "one"},
+		{"\x12o\r", "two"},
+		{"\x12o\x12\r", "one"},
+		{"ab\x12o\x07\r", "ab"},
+		{"\x12on\x05!\r", "one!"},
+		// Completing
+		{"dou\t\r", "double"},
+		{"(dou\t(1))\r", "(double(1))"},
+		{":he\t\r", ":help"},
+		{"zzz\t\r", "zzz"},
+	}
+
+	for _, tt := range tests {
+		editor := newTestEditor(tt.keys, "one", "two")
+		line, err := editor.ReadLine(PROMPT)
+		if err != nil {
+			t.Errorf("reading %q failed: %s", tt.keys, err)
+			continue
+		}
+		if line != tt.expected {
+			t.Errorf("the line of %q is not %q. got = %q", tt.keys, tt.expected, line)
+		}
+	}
+}
+
+func TestLineEditorEnd(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected error
+	}{
+		{"\x04", io.EOF},
+		{"", io.EOF},
+		{"abc\x03", errInterrupted},
+	}
+
+	for _, tt := range tests {
+		if _, err := newTestEditor(tt.keys).ReadLine(PROMPT); err != tt.expected {
+			t.Errorf("reading %q did not end with %v. got = %v", tt.keys, tt.expected, err)
+		}
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+
+	editor := newTestEditor("one\rtwo\r\r two\rtwo\r")
+	editor.historyFile = file
+	for i := 0; i < 5; i++ {
+		if _, err := editor.ReadLine(PROMPT); err != nil {
+			t.Fatalf("reading line %d failed: %s", i, err)
+		}
+	}
+
+	// Blank lines and repeats of the last line are left out
+	expected := "one\ntwo\n two\ntwo\n"
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expected {
+		t.Errorf("the history file is not %q. got = %q", expected, content)
+	}
+
+	// The next session starts with the history of the last one
+	next := newTestEditor("\x1b[A\x1b[A\r")
+	next.historyFile = file
+	next.loadHistory()
+	if line, _ := next.ReadLine(PROMPT); line != " two" {
+		t.Errorf("the line before the last one is not %q. got = %q", " two", line)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	r := New(&bytes.Buffer{})
+	r.Eval("let double = fn(x) { x * 2 }; let done = true;")
+
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{"do", "done,double"},
+		{"le", "len,let"},
+		{"ret", "return"},
+		{":re", ":reset"},
+		{":", ":ast,:env,:help,:load,:reset,:save,:time,:tokens"},
+		{"zzz", ""},
+	}
+
+	for _, tt := range tests {
+		if got := strings.Join(r.complete(tt.word), ","); got != tt.expected {
+			t.Errorf("the completions of %q are not %q. got = %q", tt.word, tt.expected, got)
+		}
+	}
+}
+
+func TestScannerReader(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out)
+
+	// Anything but a terminal is read with a scanner
+	reader := r.newLineReader(strings.NewReader("one\ntwo"))
+	if _, ok := reader.(*scannerReader); !ok {
+		t.Fatalf("the line reader of a string is not a scanner. got = %T", reader)
+	}
+
+	for _, expected := range []string{"one", "two"} {
+		if line, err := reader.ReadLine(PROMPT); err != nil || line != expected {
+			t.Errorf("the line read is not %q. got = %q, %v", expected, line, err)
+		}
+	}
+	if _, err := reader.ReadLine(CONTINUATION_PROMPT); err != io.EOF {
+		t.Errorf("the end of the input is not io.EOF. got = %v", err)
+	}
+	if out.String() != PROMPT+PROMPT+CONTINUATION_PROMPT {
+		t.Errorf("the prompts printed are not %q. got = %q", PROMPT+PROMPT+CONTINUATION_PROMPT, out.String())
+	}
+}
+
+func TestPipeIsNotTerminal(t *testing.T) {
+	in, out, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer out.Close()
+
+	if IsTerminal(in) {
+		t.Errorf("a pipe is a terminal")
+	}
+	if _, ok := New(&bytes.Buffer{}).newLineReader(in).(*scannerReader); !ok {
+		t.Errorf("the line reader of a pipe is not a scanner")
+	}
+}
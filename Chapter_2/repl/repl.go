@@ -1,28 +1,257 @@
 package repl
 
 import (
+	"Chapter_2/ast"
+	"Chapter_2/evaluator"
 	"Chapter_2/lexer"
+	"Chapter_2/object"
+	"Chapter_2/parser"
 	"Chapter_2/token"
-	"bufio"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 const PROMPT = ">> "
 
+// The prompt for the next line of incomplete input
+const CONTINUATION_PROMPT = ".. "
+
+// A REPL contains
+type REPL struct {
+	Env         *object.Environment // the environment the input is evaluated in, kept across inputs
+	Out         io.Writer           // the writer everything is printed to
+	History     []string            // the inputs evaluated without an error so far, for :save
+	HistoryFile string              // the file the lines typed on a terminal are kept in, "" to not keep them
+	Tokens      bool                // in token mode the input is only lexed, and its tokens printed
+	Color       bool                // highlight the input typed on a terminal, and color the output
+	Optimize    bool                // run the optimizer on the input before evaluating it
+	ModulePath  []string            // the directories searched for imported modules, after the one of the importing file
+	commands    map[string]Command  // the colon commands by name
+}
+
+// Create a REPL with the built-in commands
+// The history is kept in ~/.monkey_history
+func New(out io.Writer) *REPL {
+	r := &REPL{Env: object.NewEnvironment(), Out: out, commands: map[string]Command{}}
+	for _, command := range builtinCommands {
+		r.Register(command)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		r.HistoryFile = filepath.Join(home, ".monkey_history")
+	}
+	return r
+}
+
+// A function to run a REPL on some input until it ends
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	New(out).Run(in)
+}
+
+// A function to read, evaluate and print the inputs until the end of in
+func (r *REPL) Run(in io.Reader) {
+	reader := r.newLineReader(in)
+	// The lines of incomplete input so far
+	pending := []string{}
 
 	for {
-		fmt.Print(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
-			return
+		prompt := PROMPT
+		if len(pending) > 0 {
+			prompt = CONTINUATION_PROMPT
+		}
+
+		line, err := reader.ReadLine(prompt)
+		if err == errInterrupted {
+			// Ctrl-C abandons the input
+			pending = pending[:0]
+			continue
+		}
+		if err != nil {
+			if len(pending) == 0 {
+				return
+			}
+			// Ctrl-D abandons incomplete input, and the REPL goes on reading
+			fmt.Fprintln(r.Out)
+			pending = pending[:0]
+			continue
+		}
+
+		if len(pending) > 0 {
+			// A blank line abandons incomplete input
+			if strings.TrimSpace(line) == "" {
+				pending = pending[:0]
+				continue
+			}
+		} else if strings.HasPrefix(strings.TrimSpace(line), ":") {
+			r.RunCommand(strings.TrimSpace(line))
+			continue
+		}
+
+		// Wait for the rest of incomplete input, e.g. a function split across lines
+		pending = append(pending, line)
+		source := strings.Join(pending, "\n")
+		if isIncomplete(source) {
+			continue
+		}
+		pending = pending[:0]
+
+		if r.Tokens {
+			printTokens(source, r.Out)
+			continue
+		}
+		r.Eval(source)
+	}
+}
+
+// A function to evaluate some source in the environment of the REPL and print its value
+// It returns false if the source did not parse or raised an error
+func (r *REPL) Eval(source string) bool {
+	p := parser.NewParser(lexer.NewLexer(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		r.printParserErrors(source, p.Errors())
+		return false
+	}
+
+	evaluated, err := r.evalProgram(program)
+	if err != nil {
+		r.printError(err)
+		return false
+	}
+	r.History = append(r.History, source)
+	// Statements like let have no value
+	if evaluated != nil {
+		fmt.Fprintln(r.Out, r.inspect(evaluated))
+	}
+	return true
+}
+
+// A function to evaluate a program in the environment of the REPL
+// The imported modules are cached until the environment is reset
+func (r *REPL) evalProgram(program *ast.Program) (object.Object, *object.Error) {
+	if r.Env.Importer() == nil {
+		loader := evaluator.NewLoader(r.ModulePath...)
+		loader.Optimize = r.Optimize
+		r.Env.SetImporter(loader)
+	}
+	return evaluator.EvalProgram(program, r.Env, r.Optimize)
+}
+
+// A function to return the inspection string of a value, colored if the REPL has colors
+func (r *REPL) inspect(value object.Object) string {
+	if r.Color {
+		return colorInspect(value)
+	}
+	return value.Inspect()
+}
+
+// A function to print an uncaught error with its stack trace
+func (r *REPL) printError(err *object.Error) {
+	if r.Color {
+		fmt.Fprint(r.Out, colorize(errorColor, strings.TrimSuffix(err.StackTrace(), "\n"))+"\n")
+		return
+	}
+	fmt.Fprint(r.Out, err.StackTrace())
+}
+
+// A function to return the completions of a word: the commands for a word starting with ":",
+// and otherwise the keywords, the builtins and the names of the environment
+func (r *REPL) complete(word string) []string {
+	candidates := []string{}
+	if strings.HasPrefix(word, ":") {
+		for name := range r.commands {
+			candidates = append(candidates, ":"+name)
+		}
+	} else {
+		for keyword := range token.Keywords {
+			candidates = append(candidates, keyword)
+		}
+		candidates = append(candidates, evaluator.BuiltinNames()...)
+		candidates = append(candidates, r.Env.Names()...)
+	}
+
+	completions := []string{}
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) && !seen[candidate] {
+			completions = append(completions, candidate)
+			seen[candidate] = true
+		}
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// A function to report whether some source needs more lines:
+// a bracket is still open, or it ends with an infix operator or a comma
+func isIncomplete(source string) bool {
+	depth := 0
+	last := token.Token{Type: token.EOF}
+
+	l := lexer.NewLexer(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.PLBRACKET, token.RLBRACKET, token.SLBRACKET:
+			depth += 1
+		case token.PRBRACKET, token.RRBRACKET, token.SRBRACKET:
+			depth -= 1
 		}
-		line := scanner.Text()
-		l := lexer.NewLexer(line)
-		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Printf("%+v\n", tok)
+		last = tok
+	}
+
+	if depth > 0 {
+		return true
+	}
+	// "(" and "[" are infix operators too, but they already opened a bracket
+	return last.Type == token.COMMA || parser.Precedence(last.Type) > parser.LOWEST
+}
+
+// A function to print the tokens of some source, one per line
+func printTokens(source string, out io.Writer) {
+	l := lexer.NewLexer(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(out, "%+v\n", tok)
+	}
+}
+
+// A function to print parse errors, each with its line of source and a caret under its column:
+//
+//	1:5: expected next token to be VAR, got INT instead
+//	    let 5 = 1;
+//	        ^
+func (r *REPL) printParserErrors(source string, errors []string) {
+	lines := strings.Split(source, "\n")
+
+	for _, msg := range errors {
+		if r.Color {
+			fmt.Fprintln(r.Out, colorize(errorColor, msg))
+		} else {
+			fmt.Fprintln(r.Out, msg)
+		}
+
+		var line, column int
+		if _, err := fmt.Sscanf(msg, "%d:%d:", &line, &column); err != nil || line < 1 || line > len(lines) {
+			continue
+		}
+		text := lines[line-1]
+		if column < 1 || column > len(text)+1 {
+			continue
+		}
+
+		// Tabs are kept before the caret so it lines up with the source
+		indent := strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return r
+			}
+			return ' '
+		}, text[:column-1])
+		caret := "^"
+		if r.Color {
+			text, caret = highlight(text), colorize(errorColor, caret)
 		}
+		fmt.Fprintf(r.Out, "    %s\n    %s%s\n", text, indent, caret)
 	}
 }
@@ -0,0 +1,119 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// A function to run a REPL on some input and return what it printed
+// The history file is not kept, so the tests do not touch the home directory
+func run(input string) string {
+	var out bytes.Buffer
+	r := New(&out)
+	r.HistoryFile = ""
+	r.Run(strings.NewReader(input))
+	return out.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2\n", ">> 3\n>> "},
+		{"let x = 1;\n", ">> >> "},
+		// The bindings are kept across the inputs
+		{"let x = 1;\nx + 1\n", ">> >> 2\n>> "},
+		{"let x = 2;\nlet f = fn(a) { a * x };\nf(3)\n", ">> >> >> 6\n>> "},
+		{"\"a\"\n[1, \"b\"]\n", ">> a\n>> [1, b]\n>> "},
+		{
+			"let 5 = 1;\n",
+			">> 1:5: Expect the next token to be VAR, got INT instead\n    let 5 = 1;\n        ^\n" +
+				"1:7: cannot assign to 5\n    let 5 = 1;\n          ^\n>> ",
+		},
+		{"1 / 0\n", ">> ERROR: division by zero\n\nmain()\n\t<input>:1:3\n>> "},
+		// An error does not end the session
+		{"let x = 1;\ny\nx\n", ">> >> ERROR: undefined variable y\n\nmain()\n\t<input>:1:1\n>> 1\n>> "},
+	}
+
+	for _, tt := range tests {
+		if got := run(tt.input); got != tt.expected {
+			t.Errorf("the REPL did not print %q for %q. got = %q", tt.expected, tt.input, got)
+		}
+	}
+}
+
+func TestTokenMode(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out)
+	r.HistoryFile = ""
+	r.Tokens = true
+	r.Run(strings.NewReader("let x = 1;\nx\n"))
+
+	expected := ">> {Type:LET Literal:let Line:1 Column:1}\n" +
+		"{Type:VAR Literal:x Line:1 Column:5}\n" +
+		"{Type:= Literal:= Line:1 Column:7}\n" +
+		"{Type:INT Literal:1 Line:1 Column:9}\n" +
+		"{Type:; Literal:; Line:1 Column:10}\n" +
+		">> {Type:VAR Literal:x Line:1 Column:1}\n>> "
+	if got := out.String(); got != expected {
+		t.Errorf("the REPL did not print the tokens %q. got = %q", expected, got)
+	}
+	// Nothing is evaluated in token mode
+	if _, ok := r.Env.Get("x"); ok {
+		t.Errorf("the REPL evaluated the input in token mode")
+	}
+}
+
+func TestMultiLineInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn(a) {\n  a * 2\n};\nf(4)\n", ">> .. .. >> 8\n>> "},
+		{"1 +\n2\n", ">> .. 3\n>> "},
+		{"[1,\n2]\n", ">> .. [1, 2]\n>> "},
+		{"len(\"ab\"\n)\n", ">> .. 2\n>> "},
+		// A blank line abandons the input
+		{"fn() {\n\n5\n", ">> .. >> 5\n>> "},
+		// So does the end of the input
+		{"fn() {\n", ">> .. \n>> "},
+		// A blank line is nothing to continue
+		{"\n1\n", ">> >> 1\n>> "},
+	}
+
+	for _, tt := range tests {
+		if got := run(tt.input); got != tt.expected {
+			t.Errorf("the REPL did not print %q for %q. got = %q", tt.expected, tt.input, got)
+		}
+	}
+}
+
+func TestIsIncomplete(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected bool
+	}{
+		{"1 + 2", false},
+		{"let f = fn(a) {", true},
+		{"let f = fn(a) {\n a\n}", false},
+		{"add(1,", true},
+		{"[1, [2,", true},
+		{"1 +", true},
+		{"x ==", true},
+		// "=" is an infix operator too
+		{"let x =", true},
+		{"}", false},
+		{"", false},
+		// Brackets in strings and comments do not count
+		{"\"{\"", false},
+		{"1 // {", false},
+	}
+
+	for _, tt := range tests {
+		if got := isIncomplete(tt.source); got != tt.expected {
+			t.Errorf("isIncomplete(%q) is not %t. got = %t", tt.source, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package repl
+
+import (
+	"errors"
+	"os"
+)
+
+// Raw mode is only supported on Linux, other systems read plain lines, even from a terminal
+const canEditLines = false
+
+// A function to report whether a file is a terminal, which is a character device
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw mode is not supported on this system")
+}
@@ -0,0 +1,296 @@
+package resolver
+
+// Static scope resolution
+//
+// The resolver walks a program before it runs and binds every Variable
+// to the let, parameter, loop variable or catch parameter that declares it,
+// or to a builtin
+// It reports the names that are undefined, used before their definition,
+// and the parameters declared twice
+
+import (
+	"Chapter_2/ast"
+	"fmt"
+)
+
+// A frame holds the locals of a function call, or of the program outside of any function
+type frame struct {
+	outer    *frame                        // the frame the function was created in, nil for the program
+	locals   int                           // the number of locals declared in the frame
+	captured map[*ast.Binding]*ast.Binding // the free bindings of the frame, by the binding they capture
+}
+
+// A scope contains the names declared in a program, a function or a block
+type scope struct {
+	outer    *scope
+	frame    *frame
+	global   bool                    // true for the top level of the program
+	bindings map[string]*ast.Binding // the names declared in the scope
+	defined  map[string]bool         // the names whose declaration has been reached
+}
+
+func newScope(outer *scope, f *frame) *scope {
+	return &scope{
+		outer:    outer,
+		frame:    f,
+		bindings: map[string]*ast.Binding{},
+		defined:  map[string]bool{},
+	}
+}
+
+// A Resolver contains
+type Resolver struct {
+	builtins map[string]*ast.Binding
+	global   *scope // kept between programs, so a REPL can resolve one line at a time
+	globals  int    // the number of globals declared so far
+	errors   []string
+}
+
+// Create a resolver that knows the names of the builtins
+func New(builtins ...string) *Resolver {
+	r := &Resolver{builtins: map[string]*ast.Binding{}}
+	for i, name := range builtins {
+		r.builtins[name] = &ast.Binding{Name: name, Scope: ast.BuiltinScope, Index: i}
+	}
+
+	r.global = newScope(nil, &frame{captured: map[*ast.Binding]*ast.Binding{}})
+	r.global.global = true
+
+	return r
+}
+
+// A function to resolve the variables of a program
+// The globals of the program stay defined for the next programs resolved
+func (r *Resolver) Resolve(program *ast.Program) {
+	r.errors = []string{}
+	r.statements(r.global, program.Statements)
+}
+
+// A function to return the errors of the last program resolved
+func (r *Resolver) Errors() []string {
+	return r.errors
+}
+
+func (r *Resolver) errorf(variable *ast.Variable, format string, args ...any) {
+	msg := fmt.Sprintf("%s: %s", variable.Token.Position(), fmt.Sprintf(format, args...))
+	r.errors = append(r.errors, msg)
+}
+
+// A function to declare a name in a scope
+// Declaring a name again in the same scope gives the binding it already has
+func (r *Resolver) declare(s *scope, variable *ast.Variable) *ast.Binding {
+	if binding, ok := s.bindings[variable.Literal]; ok {
+		return binding
+	}
+
+	binding := &ast.Binding{Name: variable.Literal, Declaration: variable}
+	if s.global {
+		binding.Scope = ast.GlobalScope
+		binding.Index = r.globals
+		r.globals += 1
+	} else {
+		binding.Scope = ast.LocalScope
+		binding.Index = s.frame.locals
+		s.frame.locals += 1
+	}
+	s.bindings[variable.Literal] = binding
+
+	return binding
+}
+
+// A function to declare and define a name at once, e.g. a parameter
+func (r *Resolver) define(s *scope, variable *ast.Variable) {
+	variable.Binding = r.declare(s, variable)
+	s.defined[variable.Literal] = true
+}
+
+// A function to resolve a list of statements in their own scope
+// The lets of the list are declared first, so a name used before its let
+// is reported as such rather than as undefined
+func (r *Resolver) statements(s *scope, statements []ast.Statement) {
+	for _, statement := range statements {
+		if export, ok := statement.(*ast.ExportStatement); ok && export.Statement != nil {
+			statement = export.Statement
+		}
+		if let, ok := statement.(*ast.LetStatement); ok && let.Variable != nil {
+			r.declare(s, let.Variable)
+		}
+	}
+
+	for _, statement := range statements {
+		r.statement(s, statement)
+	}
+}
+
+// A function to resolve a block in a new scope
+// The variable, if any, is defined in the scope first, e.g. a loop variable
+func (r *Resolver) block(s *scope, block *ast.BlockStatement, variable *ast.Variable) {
+	if block == nil {
+		return
+	}
+
+	inner := newScope(s, s.frame)
+	if variable != nil {
+		r.define(inner, variable)
+	}
+	r.statements(inner, block.Statements)
+}
+
+func (r *Resolver) statement(s *scope, statement ast.Statement) {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		// The value is resolved before the name is defined: "let x = x;" uses x before its definition
+		r.expression(s, statement.Expression)
+		if statement.Variable != nil {
+			r.define(s, statement.Variable)
+		}
+
+	case *ast.ExportStatement:
+		if statement.Statement != nil {
+			r.statement(s, statement.Statement)
+		}
+
+	case *ast.ReturnStatement:
+		r.expression(s, statement.ReturnValue)
+
+	case *ast.ExpressionStatement:
+		r.expression(s, statement.Expression)
+
+	case *ast.ThrowStatement:
+		r.expression(s, statement.Value)
+
+	case *ast.BlockStatement:
+		r.block(s, statement, nil)
+
+	case *ast.TryStatement:
+		r.block(s, statement.Block, nil)
+		r.block(s, statement.Catch, statement.CatchParam)
+		r.block(s, statement.Finally, nil)
+
+	case *ast.WhileStatement:
+		r.expression(s, statement.Condition)
+		r.block(s, statement.Body, nil)
+
+	case *ast.ForStatement:
+		r.expression(s, statement.Iterable)
+		r.block(s, statement.Body, statement.Variable)
+
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		// Labels are not variables
+	}
+}
+
+func (r *Resolver) expression(s *scope, expression ast.Expression) {
+	switch expression := expression.(type) {
+	case *ast.Variable:
+		r.variable(s, expression)
+
+	case *ast.PrefixExpression:
+		r.expression(s, expression.Right)
+
+	case *ast.InfixExpression:
+		r.expression(s, expression.LeftValue)
+		r.expression(s, expression.RightValue)
+
+	case *ast.IndexExpression:
+		r.expression(s, expression.Left)
+		r.expression(s, expression.Index)
+
+	case *ast.AssignExpression:
+		r.expression(s, expression.Target)
+		r.expression(s, expression.Value)
+
+	case *ast.ArrayLiteral:
+		for _, element := range expression.Elements {
+			r.expression(s, element)
+		}
+
+	case *ast.IfExpression:
+		r.expression(s, expression.Condition)
+		r.block(s, expression.Consequence, nil)
+		r.block(s, expression.Alternative, nil)
+
+	case *ast.CallExpression:
+		r.expression(s, expression.Function)
+		for _, argument := range expression.Arguments {
+			r.expression(s, argument)
+		}
+
+	case *ast.FunctionLiteral:
+		r.function(s, expression)
+	}
+}
+
+// A function to resolve a function literal in a new frame
+// The parameters and the lets of the body share the scope of the function
+func (r *Resolver) function(s *scope, function *ast.FunctionLiteral) {
+	f := &frame{outer: s.frame, captured: map[*ast.Binding]*ast.Binding{}}
+	inner := newScope(s, f)
+
+	for _, parameter := range function.Parameters {
+		if _, ok := inner.bindings[parameter.Literal]; ok {
+			r.errorf(parameter, "duplicate parameter %s", parameter.Literal)
+			continue
+		}
+		r.define(inner, parameter)
+	}
+
+	if function.Body != nil {
+		r.statements(inner, function.Body.Statements)
+	}
+}
+
+// A function to bind a variable to the innermost declaration of its name
+func (r *Resolver) variable(s *scope, variable *ast.Variable) {
+	for owner := s; owner != nil; owner = owner.outer {
+		binding, ok := owner.bindings[variable.Literal]
+		if !ok {
+			continue
+		}
+
+		// A function may use a name declared later in an enclosing scope,
+		// since it only runs after the declaration has been reached
+		if !owner.defined[variable.Literal] && owner.frame == s.frame {
+			r.errorf(variable, "%s is used before its definition", variable.Literal)
+		}
+
+		if binding.Scope == ast.GlobalScope {
+			variable.Binding = binding
+		} else {
+			variable.Binding = s.frame.capture(binding, owner.frame)
+		}
+		return
+	}
+
+	if binding, ok := r.builtins[variable.Literal]; ok {
+		variable.Binding = binding
+		return
+	}
+
+	r.errorf(variable, "undefined variable %s", variable.Literal)
+}
+
+// A function to return the binding a frame uses for a local of the owner frame
+// Every function between the two captures the local as a free binding
+func (f *frame) capture(binding *ast.Binding, owner *frame) *ast.Binding {
+	if f == owner {
+		return binding
+	}
+
+	if free, ok := f.captured[binding]; ok {
+		return free
+	}
+
+	// The enclosing function captures the local first, so the closure can copy it from there
+	f.outer.capture(binding, owner)
+
+	free := &ast.Binding{
+		Name:        binding.Name,
+		Scope:       ast.FreeScope,
+		Index:       len(f.captured),
+		Declaration: binding.Declaration,
+	}
+	f.captured[binding] = free
+
+	return free
+}
@@ -0,0 +1,150 @@
+package resolver
+
+import (
+	"Chapter_2/ast"
+	"Chapter_2/lexer"
+	"Chapter_2/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.NewParser(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser has errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+// A function to return the variables of a program named name, in source order
+func variablesNamed(program *ast.Program, name string) []*ast.Variable {
+	variables := []*ast.Variable{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if variable, ok := node.(*ast.Variable); ok && variable.Literal == name {
+			variables = append(variables, variable)
+		}
+		return true
+	})
+	return variables
+}
+
+func TestResolveBindings(t *testing.T) {
+	tests := []struct {
+		input string
+		name  string
+		scope ast.BindingScope
+		index int
+	}{
+		// The binding of the last use of the name
+		{"let a = 1; let b = 2; b;", "b", ast.GlobalScope, 1},
+		{"len;", "len", ast.BuiltinScope, 0},
+		{"let f = fn(x, y) { y; };", "y", ast.LocalScope, 1},
+		{"let f = fn(x) { let z = x; z; };", "z", ast.LocalScope, 1},
+		{"let items = 1; for (item in items) { item; }", "item", ast.LocalScope, 0},
+		{"try { 1; } catch (e) { e; }", "e", ast.LocalScope, 0},
+		{"let f = fn(x) { fn() { x; }; };", "x", ast.FreeScope, 0},
+		{"let f = fn(x, y) { fn() { fn() { y; }; }; };", "y", ast.FreeScope, 0},
+		{"let x = 1; let f = fn() { x; };", "x", ast.GlobalScope, 0},
+		{"let f = fn() { f; };", "f", ast.GlobalScope, 0},
+		{`let m = import "m"; export let f = fn() { m; };`, "m", ast.GlobalScope, 0},
+		{"let x = 1; while (x) { let x = 2; x; }", "x", ast.LocalScope, 0},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		r := New("len", "puts")
+		r.Resolve(program)
+
+		if len(r.Errors()) > 0 {
+			t.Errorf("resolver has errors for %q: %v", tt.input, r.Errors())
+			continue
+		}
+
+		variables := variablesNamed(program, tt.name)
+		binding := variables[len(variables)-1].Binding
+		if binding == nil {
+			t.Errorf("%s is not resolved in %q", tt.name, tt.input)
+			continue
+		}
+
+		if binding.Scope != tt.scope || binding.Index != tt.index {
+			t.Errorf("%s in %q is not %s %d. got = %s %d", tt.name, tt.input, tt.scope, tt.index, binding.Scope, binding.Index)
+		}
+	}
+}
+
+func TestResolveDeclarations(t *testing.T) {
+	program := parse(t, "let x = 1; x = x + 1;")
+	r := New()
+	r.Resolve(program)
+
+	variables := variablesNamed(program, "x")
+	for _, variable := range variables {
+		if variable.Binding != variables[0].Binding {
+			t.Errorf("the uses of x do not share the binding of its declaration")
+		}
+	}
+
+	if variables[0].Binding.Declaration != variables[0] {
+		t.Errorf("binding.Declaration is not the declared variable")
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x;", "1:1: undefined variable x"},
+		{"let y = 1;\nx = y;", "2:1: undefined variable x"},
+		{"let f = fn(a, b, a) { a; };", "1:18: duplicate parameter a"},
+		{"x; let x = 1;", "1:1: x is used before its definition"},
+		{"x; export let x = 1;", "1:1: x is used before its definition"},
+		{"let x = x;", "1:9: x is used before its definition"},
+		{"while (1) { x; let x = 2; }", "1:13: x is used before its definition"},
+		{"for (x in xs) { 1; }", "1:11: undefined variable xs"},
+		{"while (1) { let x = 1; } x;", "1:26: undefined variable x"},
+		{"try { 1; } catch (e) { 1; } e;", "1:29: undefined variable e"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		r := New()
+		r.Resolve(program)
+
+		if len(r.Errors()) != 1 {
+			t.Errorf("resolver does not have 1 error for %q. got = %v", tt.input, r.Errors())
+			continue
+		}
+
+		if r.Errors()[0] != tt.expected {
+			t.Errorf("the error for %q is not %q. got = %q", tt.input, tt.expected, r.Errors()[0])
+		}
+	}
+}
+
+func TestResolveLaterDeclarationInFunction(t *testing.T) {
+	program := parse(t, "let f = fn() { g(); }; let g = fn() { 1; };")
+	r := New()
+	r.Resolve(program)
+
+	if len(r.Errors()) > 0 {
+		t.Errorf("resolver has errors: %v", r.Errors())
+	}
+}
+
+func TestResolveKeepsGlobals(t *testing.T) {
+	r := New()
+	r.Resolve(parse(t, "let x = 1;"))
+	program := parse(t, "x;")
+	r.Resolve(program)
+
+	if len(r.Errors()) > 0 {
+		t.Fatalf("resolver has errors: %v", r.Errors())
+	}
+
+	binding := variablesNamed(program, "x")[0].Binding
+	if binding.Scope != ast.GlobalScope {
+		t.Errorf("x is not %s. got = %s", ast.GlobalScope, binding.Scope)
+	}
+}
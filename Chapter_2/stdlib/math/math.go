@@ -0,0 +1,300 @@
+package math
+
+// The math functions of the standard library
+//
+// Importing the package registers them, and the constants PI and E, as the module "math":
+//
+//	let math = import "math";
+//	math["sqrt"](math["PI"]);
+//
+//	abs  min  max  pow  sqrt  floor  ceil  round  clamp  gcd  random
+//
+// The functions take integers and floats, an integer stays an integer when the result is one
+// The results that do not exist, or are too large, are errors a program can catch
+
+import (
+	"Chapter_2/evaluator"
+	"Chapter_2/object"
+	"Chapter_2/stdlib"
+	gomath "math"
+	"math/big"
+	"math/rand"
+)
+
+// The largest integer pow makes, in bits, so that a wrong exponent fails instead of taking all the memory
+const maxPowBits = 1 << 20
+
+// The builtins of the library
+var Builtins = []*object.Builtin{
+	{Name: "abs", Fn: abs},
+	{Name: "min", Fn: minimum},
+	{Name: "max", Fn: maximum},
+	{Name: "pow", Fn: pow},
+	{Name: "sqrt", Fn: sqrt},
+	{Name: "floor", Fn: floor},
+	{Name: "ceil", Fn: ceil},
+	{Name: "round", Fn: round},
+	{Name: "clamp", Fn: clamp},
+	{Name: "gcd", Fn: gcd},
+	{Name: "random", Fn: random},
+}
+
+// The constants of the library
+var Constants = map[string]object.Object{
+	"PI": &object.Float{Value: gomath.Pi},
+	"E":  &object.Float{Value: gomath.E},
+}
+
+func init() {
+	evaluator.RegisterModule("math", stdlib.Exports(Builtins, Constants))
+}
+
+// A function to return the number argument of a builtin that takes only a number
+func numberArgument(name string, args []object.Object) (object.Object, *object.Error) {
+	if err := stdlib.CheckArguments(args, 1); err != nil {
+		return nil, err
+	}
+	return stdlib.NumberArgument(name, args, 0)
+}
+
+// abs(x) returns the absolute value of x
+func abs(args ...object.Object) object.Object {
+	x, err := numberArgument("abs", args)
+	if err != nil {
+		return err
+	}
+
+	if float, ok := x.(*object.Float); ok {
+		return &object.Float{Value: gomath.Abs(float.Value)}
+	}
+	integer := x.(*object.Integer)
+	if integer.Big == nil && integer.Value >= 0 {
+		return integer
+	}
+	// The absolute value of the smallest int64 does not fit in an int64
+	return object.NewBigInteger(new(big.Int).Abs(integer.BigValue()))
+}
+
+// min(x, ...) returns the smallest of its arguments, or of the elements of an array
+func minimum(args ...object.Object) object.Object {
+	return extreme("min", args, -1)
+}
+
+// max(x, ...) returns the largest of its arguments, or of the elements of an array
+func maximum(args ...object.Object) object.Object {
+	return extreme("max", args, +1)
+}
+
+// A function to return the first of the numbers that compares as sign to all the others
+func extreme(name string, args []object.Object, sign int) object.Object {
+	if len(args) == 0 {
+		return stdlib.Errorf("wrong number of arguments: want at least 1, got 0")
+	}
+	if array, ok := args[0].(*object.Array); ok && len(args) == 1 {
+		if len(array.Elements) == 0 {
+			return stdlib.Errorf("`%s` of an empty array", name)
+		}
+		args = array.Elements
+	}
+
+	var result object.Object
+	for i := range args {
+		number, err := stdlib.NumberArgument(name, args, i)
+		if err != nil {
+			return err
+		}
+		if result == nil || object.CompareNumbers(number, result) == sign {
+			result = number
+		}
+	}
+	return result
+}
+
+// pow(x, y) returns x to the power y
+// It is an integer when x and y are integers and y is not negative, and a float otherwise
+func pow(args ...object.Object) object.Object {
+	if err := stdlib.CheckArguments(args, 2); err != nil {
+		return err
+	}
+	x, err := stdlib.NumberArgument("pow", args, 0)
+	if err != nil {
+		return err
+	}
+	y, err := stdlib.NumberArgument("pow", args, 1)
+	if err != nil {
+		return err
+	}
+
+	base, baseInteger := x.(*object.Integer)
+	exponent, exponentInteger := y.(*object.Integer)
+	if baseInteger && exponentInteger && exponent.BigValue().Sign() >= 0 {
+		return integerPow(base.BigValue(), exponent.BigValue())
+	}
+
+	a, err := stdlib.Float("pow", x)
+	if err != nil {
+		return err
+	}
+	b, err := stdlib.Float("pow", y)
+	if err != nil {
+		return err
+	}
+	if a == 0 && b < 0 {
+		return stdlib.Errorf("`pow` of zero to a negative power")
+	}
+
+	result := gomath.Pow(a, b)
+	if gomath.IsNaN(result) {
+		return stdlib.Errorf("`pow` of a negative number to a fractional power")
+	}
+	if gomath.IsInf(result, 0) {
+		return stdlib.Errorf("float overflow in `pow`")
+	}
+	return &object.Float{Value: result}
+}
+
+// A function to raise an integer to a power that is not negative
+func integerPow(base, exponent *big.Int) object.Object {
+	// 0, 1 and -1 stay small whatever the exponent
+	if base.CmpAbs(big.NewInt(1)) <= 0 {
+		if base.Sign() < 0 && exponent.Bit(0) == 0 {
+			return &object.Integer{Value: 1}
+		}
+		if base.Sign() == 0 && exponent.Sign() == 0 {
+			return &object.Integer{Value: 1}
+		}
+		return object.NewBigInteger(new(big.Int).Set(base))
+	}
+
+	// The result has about as many bits as the base times the exponent
+	if !exponent.IsInt64() || exponent.Int64() > maxPowBits/int64(base.BitLen()) {
+		return stdlib.Errorf("integer overflow in `pow`: the result would have more than %d bits", maxPowBits)
+	}
+	return object.NewBigInteger(new(big.Int).Exp(base, exponent, nil))
+}
+
+// sqrt(x) returns the square root of x, as a float
+func sqrt(args ...object.Object) object.Object {
+	x, err := numberArgument("sqrt", args)
+	if err != nil {
+		return err
+	}
+	value, err := stdlib.Float("sqrt", x)
+	if err != nil {
+		return err
+	}
+
+	if value < 0 {
+		return stdlib.Errorf("`sqrt` of a negative number: %s", x.Inspect())
+	}
+	return &object.Float{Value: gomath.Sqrt(value)}
+}
+
+// floor(x) returns the largest integer not greater than x
+func floor(args ...object.Object) object.Object {
+	return toInteger("floor", args, gomath.Floor)
+}
+
+// ceil(x) returns the smallest integer not less than x
+func ceil(args ...object.Object) object.Object {
+	return toInteger("ceil", args, gomath.Ceil)
+}
+
+// round(x) returns the nearest integer to x, the halves are rounded away from zero
+func round(args ...object.Object) object.Object {
+	return toInteger("round", args, gomath.Round)
+}
+
+// A function to turn a number into an integer, with the rounding of a float
+// An integer is returned as it is
+func toInteger(name string, args []object.Object, rounding func(float64) float64) object.Object {
+	x, err := numberArgument(name, args)
+	if err != nil {
+		return err
+	}
+
+	float, ok := x.(*object.Float)
+	if !ok {
+		return x
+	}
+	// A float is finite, so its rounding is always an integer
+	integer, _ := big.NewFloat(rounding(float.Value)).Int(nil)
+	return object.NewBigInteger(integer)
+}
+
+// clamp(x, low, high) returns x, or the bound it is beyond
+func clamp(args ...object.Object) object.Object {
+	if err := stdlib.CheckArguments(args, 3); err != nil {
+		return err
+	}
+	numbers := []object.Object{}
+	for i := range args {
+		number, err := stdlib.NumberArgument("clamp", args, i)
+		if err != nil {
+			return err
+		}
+		numbers = append(numbers, number)
+	}
+	x, low, high := numbers[0], numbers[1], numbers[2]
+
+	switch {
+	case object.CompareNumbers(low, high) > 0:
+		return stdlib.Errorf("lower bound %s greater than upper bound %s in `clamp`", low.Inspect(), high.Inspect())
+	case object.CompareNumbers(x, low) < 0:
+		return low
+	case object.CompareNumbers(x, high) > 0:
+		return high
+	}
+	return x
+}
+
+// gcd(a, b) returns the greatest common divisor of two integers, which is not negative
+// gcd(0, 0) is 0
+func gcd(args ...object.Object) object.Object {
+	if err := stdlib.CheckArguments(args, 2); err != nil {
+		return err
+	}
+	a, err := stdlib.IntegerArgument("gcd", args, 0)
+	if err != nil {
+		return err
+	}
+	b, err := stdlib.IntegerArgument("gcd", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return object.NewBigInteger(new(big.Int).GCD(nil, nil, a.BigValue(), b.BigValue()))
+}
+
+// random(seed) returns a generator of random numbers, the same for the same seed:
+// next() returns a float in [0, 1), and next(n) an integer in [0, n)
+func random(args ...object.Object) object.Object {
+	if err := stdlib.CheckArguments(args, 1); err != nil {
+		return err
+	}
+	seed, err := stdlib.IntegerArgument("random", args, 0)
+	if err != nil {
+		return err
+	}
+	if seed.Big != nil {
+		return stdlib.Errorf("seed to `random` too large: %s", seed.Inspect())
+	}
+
+	generator := rand.New(rand.NewSource(seed.Value))
+	return &object.Builtin{Name: "next", Fn: func(args ...object.Object) object.Object {
+		switch len(args) {
+		case 0:
+			return &object.Float{Value: generator.Float64()}
+		case 1:
+			n, err := stdlib.IntegerArgument("next", args, 0)
+			if err != nil {
+				return err
+			}
+			if n.Big != nil || n.Value <= 0 {
+				return stdlib.Errorf("argument to `next` must be positive and fit in 64 bits, got %s", n.Inspect())
+			}
+			return &object.Integer{Value: generator.Int63n(n.Value)}
+		}
+		return stdlib.Errorf("wrong number of arguments: want 0 or 1, got %d", len(args))
+	}}
+}
@@ -0,0 +1,10 @@
+package math
+
+import (
+	"Chapter_2/stdlib/stdlibtest"
+	"testing"
+)
+
+func TestScripts(t *testing.T) {
+	stdlibtest.Run(t, "testdata/*.mk")
+}
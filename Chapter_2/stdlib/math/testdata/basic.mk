@@ -0,0 +1,57 @@
+// abs, min, max, clamp, gcd and the constants
+
+let math = import "math";
+let abs = math["abs"];
+let min = math["min"];
+let max = math["max"];
+let clamp = math["clamp"];
+let gcd = math["gcd"];
+let PI = math["PI"];
+let E = math["E"];
+
+let fails = fn(f) {
+	let caught = 0;
+	try { f(); } catch (e) { caught = e["message"]; }
+	caught
+};
+
+expect(PI, 3.141592653589793);
+expect(E, 2.718281828459045);
+expect(PI > 3.14, true);
+
+expect(abs(-5), 5);
+expect(abs(5), 5);
+expect(abs(-2.5), 2.5);
+expect(abs(0.0), 0.0);
+expect(abs(-9223372036854775807 - 1), 9223372036854775808);
+expect(abs(-100000000000000000000), 100000000000000000000);
+
+expect(min(3, 1, 2), 1);
+expect(max(3, 1, 2), 3);
+expect(min(2, 1.5), 1.5);
+expect(max(2, 1.5), 2);
+expect(min([4, -1, 7]), -1);
+expect(max([4, -1, 7.5]), 7.5);
+expect(max(7), 7);
+// The first of equal numbers is returned, with its type
+expect(max(1, 1.0), 1);
+expect(min(1.0, 1), 1.0);
+
+expect(clamp(15, 0, 10), 10);
+expect(clamp(-3, 0, 10), 0);
+expect(clamp(5, 0, 10), 5);
+expect(clamp(0.5, 0, 1), 0.5);
+expect(clamp(2, 0, 1.5), 1.5);
+
+expect(gcd(12, 18), 6);
+expect(gcd(-12, 18), 6);
+expect(gcd(7, 0), 7);
+expect(gcd(0, 0), 0);
+expect(gcd(100000000000000000000, 300000000000000000000), 100000000000000000000);
+
+expect(fails(fn() { abs("a"); }), "argument to `abs` must be INTEGER or FLOAT, got STRING");
+expect(fails(fn() { min(); }), "wrong number of arguments: want at least 1, got 0");
+expect(fails(fn() { max([]); }), "`max` of an empty array");
+expect(fails(fn() { min(1, true); }), "argument 2 to `min` must be INTEGER or FLOAT, got BOOLEAN");
+expect(fails(fn() { clamp(1, 10, 0); }), "lower bound 10 greater than upper bound 0 in `clamp`");
+expect(fails(fn() { gcd(1.5, 3); }), "argument 1 to `gcd` must be INTEGER, got FLOAT");
@@ -0,0 +1,39 @@
+// pow and sqrt
+
+let math = import "math";
+let pow = math["pow"];
+let sqrt = math["sqrt"];
+
+let fails = fn(f) {
+	let caught = 0;
+	try { f(); } catch (e) { caught = e["message"]; }
+	caught
+};
+
+expect(pow(2, 10), 1024);
+expect(pow(2, 0), 1);
+expect(pow(0, 0), 1);
+expect(pow(-2, 3), -8);
+expect(pow(2, 100), 1267650600228229401496703205376);
+expect(pow(-1, 100000000000000000001), -1);
+expect(pow(1, 100000000000000000000), 1);
+// A negative exponent or a float makes a float
+expect(pow(2, -2), 0.25);
+expect(pow(2.0, 3), 8.0);
+expect(pow(4, 0.5), 2.0);
+expect(pow(-8, 3.0), -512.0);
+
+expect(sqrt(16), 4.0);
+expect(sqrt(2), 1.4142135623730951);
+expect(sqrt(0.25), 0.5);
+expect(sqrt(0), 0.0);
+
+expect(fails(fn() { pow(2, 10000000); }), "integer overflow in `pow`: the result would have more than 1048576 bits");
+expect(fails(fn() { pow(10, 100000000000000000000); }), "integer overflow in `pow`: the result would have more than 1048576 bits");
+expect(fails(fn() { pow(10.0, 400); }), "float overflow in `pow`");
+expect(fails(fn() { pow(0, -1); }), "`pow` of zero to a negative power");
+expect(fails(fn() { pow(-8, 0.5); }), "`pow` of a negative number to a fractional power");
+expect(fails(fn() { pow(2); }), "wrong number of arguments: want=2, got=1");
+expect(fails(fn() { sqrt(-4); }), "`sqrt` of a negative number: -4");
+expect(fails(fn() { sqrt(-0.5); }), "`sqrt` of a negative number: -0.5");
+expect(fails(fn() { sqrt(pow(10, 400)); }), "integer too big for a float in `sqrt`");
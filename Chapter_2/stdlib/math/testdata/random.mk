@@ -0,0 +1,46 @@
+// random, a generator of random numbers from a seed
+
+let math = import "math";
+let random = math["random"];
+
+let fails = fn(f) {
+	let caught = 0;
+	try { f(); } catch (e) { caught = e["message"]; }
+	caught
+};
+
+// The same seed gives the same numbers
+let a = random(42);
+let b = random(42);
+let same = true;
+let i = 0;
+while (i < 100) {
+	if (a() != b()) { same = false; }
+	if (a(1000) != b(1000)) { same = false; }
+	i += 1;
+}
+expect(same, true);
+
+// Another seed gives other numbers
+expect(random(1)() != random(2)(), true);
+
+// next() is in [0, 1), next(n) in [0, n)
+let next = random(7);
+let inRange = true;
+i = 0;
+while (i < 1000) {
+	let x = next();
+	if (x < 0) { inRange = false; }
+	if (!(x < 1)) { inRange = false; }
+	let n = next(6);
+	if (n < 0) { inRange = false; }
+	if (n > 5) { inRange = false; }
+	i += 1;
+}
+expect(inRange, true);
+expect(next(1), 0);
+
+expect(fails(fn() { random(1.5); }), "argument to `random` must be INTEGER, got FLOAT");
+expect(fails(fn() { random(100000000000000000000); }), "seed to `random` too large: 100000000000000000000");
+expect(fails(fn() { next(0); }), "argument to `next` must be positive and fit in 64 bits, got 0");
+expect(fails(fn() { next(1, 2); }), "wrong number of arguments: want 0 or 1, got 2");
@@ -0,0 +1,31 @@
+// floor, ceil and round, which make integers
+
+let math = import "math";
+let floor = math["floor"];
+let ceil = math["ceil"];
+let round = math["round"];
+
+let fails = fn(f) {
+	let caught = 0;
+	try { f(); } catch (e) { caught = e["message"]; }
+	caught
+};
+
+expect(floor(2.7), 2);
+expect(floor(-2.5), -3);
+expect(floor(5), 5);
+expect(ceil(2.1), 3);
+expect(ceil(-2.5), -2);
+expect(ceil(2.0), 2);
+
+expect(round(2.4), 2);
+expect(round(2.5), 3);
+expect(round(-2.5), -3);
+expect(round(100000000000000000000), 100000000000000000000);
+expect(round(1e20), 100000000000000000000);
+
+// The result is an integer, it can index an array
+expect([10, 20, 30][floor(1.9)], 20);
+
+expect(fails(fn() { floor("1.5"); }), "argument to `floor` must be INTEGER or FLOAT, got STRING");
+expect(fails(fn() { round(1.5, 2); }), "wrong number of arguments: want=1, got=2");
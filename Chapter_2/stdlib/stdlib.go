@@ -0,0 +1,112 @@
+package stdlib
+
+// Helpers for the builtins of the libraries in the directories below
+//
+// A library is a package that registers a module with the evaluator when it is imported
+// Programs import the module by its name, e.g. import "strings", and read its builtins from it
+// Its builtins check their arguments with these helpers, so they all report the same errors
+
+import (
+	"Chapter_2/evaluator"
+	"Chapter_2/object"
+	"fmt"
+)
+
+// The type the number arguments must have, in their errors
+const NUMBER = object.INTEGER_OBJ + " or " + object.FLOAT_OBJ
+
+// A function to return the exports of a library: its builtins by their names, and its constants
+func Exports(builtins []*object.Builtin, constants map[string]object.Object) map[string]object.Object {
+	exports := map[string]object.Object{}
+	for _, builtin := range builtins {
+		exports[builtin.Name] = builtin
+	}
+	for name, value := range constants {
+		exports[name] = value
+	}
+	return exports
+}
+
+// A function to make the error of a builtin
+// It has no position, the evaluator raises it at the call
+func Errorf(format string, a ...any) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+// A function to check the number of arguments of a builtin
+func CheckArguments(args []object.Object, want int) *object.Error {
+	if len(args) != want {
+		return Errorf("wrong number of arguments: want=%d, got=%d", want, len(args))
+	}
+	return nil
+}
+
+// A function to return the error of an argument of the wrong type
+// The argument is numbered from 1 when the builtin takes several
+func ArgumentError(name string, args []object.Object, i int, want object.ObjectType) *object.Error {
+	if len(args) == 1 {
+		return Errorf("argument to `%s` must be %s, got %s", name, want, args[i].Type())
+	}
+	return Errorf("argument %d to `%s` must be %s, got %s", i+1, name, want, args[i].Type())
+}
+
+// A function to return an argument of a builtin that must be a string
+func StringArgument(name string, args []object.Object, i int) (string, *object.Error) {
+	str, ok := args[i].(*object.String)
+	if !ok {
+		return "", ArgumentError(name, args, i, object.STRING_OBJ)
+	}
+	return str.Value, nil
+}
+
+// A function to return an argument of a builtin that must be an integer
+func IntegerArgument(name string, args []object.Object, i int) (*object.Integer, *object.Error) {
+	integer, ok := args[i].(*object.Integer)
+	if !ok {
+		return nil, ArgumentError(name, args, i, object.INTEGER_OBJ)
+	}
+	return integer, nil
+}
+
+// A function to return an argument of a builtin that must be a number, an INTEGER or a FLOAT
+func NumberArgument(name string, args []object.Object, i int) (object.Object, *object.Error) {
+	switch args[i].(type) {
+	case *object.Integer, *object.Float:
+		return args[i], nil
+	}
+	return nil, ArgumentError(name, args, i, NUMBER)
+}
+
+// A function to return a number as a float64
+// An integer too big for a float64 is an error
+func Float(name string, number object.Object) (float64, *object.Error) {
+	switch number := number.(type) {
+	case *object.Integer:
+		value, ok := number.Float64()
+		if !ok {
+			return 0, Errorf("integer too big for a float in `%s`", name)
+		}
+		return value, nil
+	case *object.Float:
+		return number.Value, nil
+	}
+	return 0, Errorf("argument to `%s` must be %s, got %s", name, NUMBER, number.Type())
+}
+
+// A function to return an argument of a builtin that must be an array
+func ArrayArgument(name string, args []object.Object, i int) (*object.Array, *object.Error) {
+	array, ok := args[i].(*object.Array)
+	if !ok {
+		return nil, ArgumentError(name, args, i, object.ARRAY_OBJ)
+	}
+	return array, nil
+}
+
+// A function to return the boolean object of a Go boolean
+// There is only one true and one false, which conditions compare against
+func Boolean(value bool) *object.Boolean {
+	if value {
+		return evaluator.TRUE
+	}
+	return evaluator.FALSE
+}
@@ -0,0 +1,87 @@
+package stdlibtest
+
+// The tests of the libraries, written as Monkey scripts
+//
+// A library test runs the scripts of its testdata directory
+// A script checks its results with expect(got, want), which fails the script at the call
+// when got and want differ in type or in value
+// A caught error is expected by its message, e.g.
+//
+//	let strings = import "strings";
+//	let e = 0;
+//	try { strings["split"](1, ""); } catch (err) { e = err["message"]; }
+//	expect(e, "argument 1 to `split` must be STRING, got INTEGER");
+
+import (
+	"Chapter_2/evaluator"
+	"Chapter_2/lexer"
+	"Chapter_2/object"
+	"Chapter_2/parser"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// A function to run the scripts matching a pattern, each as a subtest
+func Run(t *testing.T, pattern string) {
+	t.Helper()
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatalf("bad pattern %q: %v", pattern, err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no scripts match %q", pattern)
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			runScript(t, file)
+		})
+	}
+}
+
+// A function to evaluate a script and report its failure
+func runScript(t *testing.T, file string) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := parser.NewParser(lexer.NewLexer(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("%s:%s", file, p.Errors()[0])
+	}
+
+	env := object.NewEnvironment()
+	env.SetFile(file)
+	env.Set("expect", &object.Builtin{Name: "expect", Fn: expect})
+	if _, err := evaluator.EvalProgram(program, env, false); err != nil {
+		t.Errorf("%s", err.StackTrace())
+	}
+}
+
+// expect(got, want) fails when got is not want
+func expect(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return &object.Error{Message: fmt.Sprintf("wrong number of arguments: want=2, got=%d", len(args))}
+	}
+	got, want := args[0], args[1]
+
+	if got.Type() == want.Type() && got.Inspect() == want.Inspect() {
+		return evaluator.NULL
+	}
+
+	return &object.Error{Message: fmt.Sprintf("expected %s, got %s", describe(want), describe(got))}
+}
+
+// A function to describe a value in the failure of expect
+func describe(value object.Object) string {
+	if value.Type() == object.STRING_OBJ {
+		return strconv.Quote(value.Inspect())
+	}
+	return fmt.Sprintf("%s (%s)", value.Inspect(), value.Type())
+}
@@ -0,0 +1,260 @@
+package strings
+
+// The string functions of the standard library
+//
+// Importing the package registers them as the module "strings":
+//
+//	let strings = import "strings";
+//	strings["split"]("a,b", ",");
+//
+//	split  join  trim  replace  contains  index_of
+//	upper  lower  starts_with  ends_with  repeat  format
+//
+// Strings are UTF-8, and the positions are counted in characters, not in bytes
+
+import (
+	"Chapter_2/evaluator"
+	"Chapter_2/object"
+	"Chapter_2/stdlib"
+	gostrings "strings"
+	"unicode/utf8"
+)
+
+// The longest string repeat makes, so that a wrong count fails instead of taking all the memory
+const maxRepeatLength = 1 << 30
+
+// The builtins of the library
+var Builtins = []*object.Builtin{
+	{Name: "split", Fn: split},
+	{Name: "join", Fn: join},
+	{Name: "trim", Fn: trim},
+	{Name: "replace", Fn: replace},
+	{Name: "contains", Fn: contains},
+	{Name: "index_of", Fn: indexOf},
+	{Name: "upper", Fn: upper},
+	{Name: "lower", Fn: lower},
+	{Name: "starts_with", Fn: startsWith},
+	{Name: "ends_with", Fn: endsWith},
+	{Name: "repeat", Fn: repeat},
+	{Name: "format", Fn: format},
+}
+
+func init() {
+	evaluator.RegisterModule("strings", stdlib.Exports(Builtins, nil))
+}
+
+// A function to return the string arguments of a builtin that takes count strings
+func stringArguments(name string, args []object.Object, count int) ([]string, *object.Error) {
+	if err := stdlib.CheckArguments(args, count); err != nil {
+		return nil, err
+	}
+	values := []string{}
+	for i := range args {
+		value, err := stdlib.StringArgument(name, args, i)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// split(s, sep) returns the parts of s between the separators
+// An empty separator splits s into its characters
+func split(args ...object.Object) object.Object {
+	values, err := stringArguments("split", args, 2)
+	if err != nil {
+		return err
+	}
+
+	elements := []object.Object{}
+	for _, part := range gostrings.Split(values[0], values[1]) {
+		elements = append(elements, &object.String{Value: part})
+	}
+	return &object.Array{Elements: elements}
+}
+
+// join(array, sep) joins an array of strings with a separator
+func join(args ...object.Object) object.Object {
+	if err := stdlib.CheckArguments(args, 2); err != nil {
+		return err
+	}
+	array, err := stdlib.ArrayArgument("join", args, 0)
+	if err != nil {
+		return err
+	}
+	sep, err := stdlib.StringArgument("join", args, 1)
+	if err != nil {
+		return err
+	}
+
+	parts := []string{}
+	for i, element := range array.Elements {
+		str, ok := element.(*object.String)
+		if !ok {
+			return stdlib.Errorf("element %d of the array to `join` must be STRING, got %s", i, element.Type())
+		}
+		parts = append(parts, str.Value)
+	}
+	return &object.String{Value: gostrings.Join(parts, sep)}
+}
+
+// trim(s) removes the white space around s
+func trim(args ...object.Object) object.Object {
+	values, err := stringArguments("trim", args, 1)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: gostrings.TrimSpace(values[0])}
+}
+
+// replace(s, old, new) replaces every old in s with new
+func replace(args ...object.Object) object.Object {
+	values, err := stringArguments("replace", args, 3)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: gostrings.ReplaceAll(values[0], values[1], values[2])}
+}
+
+// contains(s, sub) reports whether sub is in s
+func contains(args ...object.Object) object.Object {
+	values, err := stringArguments("contains", args, 2)
+	if err != nil {
+		return err
+	}
+	return stdlib.Boolean(gostrings.Contains(values[0], values[1]))
+}
+
+// index_of(s, sub) returns the position of the first sub in s, in characters, or -1
+func indexOf(args ...object.Object) object.Object {
+	values, err := stringArguments("index_of", args, 2)
+	if err != nil {
+		return err
+	}
+
+	index := gostrings.Index(values[0], values[1])
+	if index < 0 {
+		return &object.Integer{Value: -1}
+	}
+	return &object.Integer{Value: int64(utf8.RuneCountInString(values[0][:index]))}
+}
+
+// upper(s) returns s in upper case
+func upper(args ...object.Object) object.Object {
+	values, err := stringArguments("upper", args, 1)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: gostrings.ToUpper(values[0])}
+}
+
+// lower(s) returns s in lower case
+func lower(args ...object.Object) object.Object {
+	values, err := stringArguments("lower", args, 1)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: gostrings.ToLower(values[0])}
+}
+
+// starts_with(s, prefix) reports whether s starts with prefix
+func startsWith(args ...object.Object) object.Object {
+	values, err := stringArguments("starts_with", args, 2)
+	if err != nil {
+		return err
+	}
+	return stdlib.Boolean(gostrings.HasPrefix(values[0], values[1]))
+}
+
+// ends_with(s, suffix) reports whether s ends with suffix
+func endsWith(args ...object.Object) object.Object {
+	values, err := stringArguments("ends_with", args, 2)
+	if err != nil {
+		return err
+	}
+	return stdlib.Boolean(gostrings.HasSuffix(values[0], values[1]))
+}
+
+// repeat(s, count) returns count copies of s
+func repeat(args ...object.Object) object.Object {
+	if err := stdlib.CheckArguments(args, 2); err != nil {
+		return err
+	}
+	s, err := stdlib.StringArgument("repeat", args, 0)
+	if err != nil {
+		return err
+	}
+	count, err := stdlib.IntegerArgument("repeat", args, 1)
+	if err != nil {
+		return err
+	}
+
+	if (count.Big == nil && count.Value < 0) || (count.Big != nil && count.Big.Sign() < 0) {
+		return stdlib.Errorf("negative count to `repeat`: %s", count.Inspect())
+	}
+	if len(s) > 0 && (count.Big != nil || count.Value > maxRepeatLength/int64(len(s))) {
+		return stdlib.Errorf("count to `repeat` too large: %s", count.Inspect())
+	}
+	return &object.String{Value: gostrings.Repeat(s, int(count.Value))}
+}
+
+// format(template, args...) replaces the verbs of template with the arguments, in order:
+// %d an integer, %s a string, %v any value, and %% a percent sign
+func format(args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return stdlib.Errorf("wrong number of arguments: want at least 1, got 0")
+	}
+	template, err := stdlib.StringArgument("format", args, 0)
+	if err != nil {
+		return err
+	}
+
+	var out gostrings.Builder
+	next := 1
+	verb := false
+	for _, char := range template {
+		if !verb {
+			if char == '%' {
+				verb = true
+			} else {
+				out.WriteRune(char)
+			}
+			continue
+		}
+		verb = false
+
+		if char == '%' {
+			out.WriteRune('%')
+			continue
+		}
+		if next == len(args) {
+			return stdlib.Errorf("missing argument for %%%c in `format`", char)
+		}
+		arg := args[next]
+		next += 1
+
+		switch char {
+		case 'd':
+			if arg.Type() != object.INTEGER_OBJ {
+				return stdlib.Errorf("%%d in `format` must be INTEGER, got %s", arg.Type())
+			}
+		case 's':
+			if arg.Type() != object.STRING_OBJ {
+				return stdlib.Errorf("%%s in `format` must be STRING, got %s", arg.Type())
+			}
+		case 'v':
+		default:
+			return stdlib.Errorf("unknown verb %%%c in `format`", char)
+		}
+		out.WriteString(arg.Inspect())
+	}
+
+	if verb {
+		return stdlib.Errorf("missing verb after %% at the end of the template of `format`")
+	}
+	if next < len(args) {
+		return stdlib.Errorf("too many arguments to `format`: %d left over", len(args)-next)
+	}
+	return &object.String{Value: out.String()}
+}
@@ -0,0 +1,28 @@
+// format
+
+let strings = import "strings";
+let format = strings["format"];
+
+let fails = fn(f) {
+	let caught = 0;
+	try { f(); } catch (e) { caught = e["message"]; }
+	caught
+};
+
+expect(format("plain"), "plain");
+expect(format("%d + %d = %d", 1, 2, 3), "1 + 2 = 3");
+expect(format("hello, %s!", "wörld"), "hello, wörld!");
+expect(format("%v and %v", [1, "a"], true), "[1, a] and true");
+expect(format("100%%"), "100%");
+expect(format("%s%%", "50"), "50%");
+expect(format("→%s←", "日本"), "→日本←");
+expect(format("%d", 100000000000000000000), "100000000000000000000");
+
+expect(fails(fn() { format(); }), "wrong number of arguments: want at least 1, got 0");
+expect(fails(fn() { format(1); }), "argument to `format` must be STRING, got INTEGER");
+expect(fails(fn() { format("%d", "a"); }), "%d in `format` must be INTEGER, got STRING");
+expect(fails(fn() { format("%s", 1); }), "%s in `format` must be STRING, got INTEGER");
+expect(fails(fn() { format("%d %d", 1); }), "missing argument for %d in `format`");
+expect(fails(fn() { format("%x", 1); }), "unknown verb %x in `format`");
+expect(fails(fn() { format("50%"); }), "missing verb after % at the end of the template of `format`");
+expect(fails(fn() { format("%d", 1, 2); }), "too many arguments to `format`: 1 left over");
@@ -0,0 +1,42 @@
+// contains, index_of, starts_with and ends_with
+
+let strings = import "strings";
+let contains = strings["contains"];
+let index_of = strings["index_of"];
+let starts_with = strings["starts_with"];
+let ends_with = strings["ends_with"];
+
+let fails = fn(f) {
+	let caught = 0;
+	try { f(); } catch (e) { caught = e["message"]; }
+	caught
+};
+
+expect(contains("monkey", "key"), true);
+expect(contains("monkey", "donkey"), false);
+expect(contains("monkey", ""), true);
+expect(contains("naïve", "ï"), true);
+
+expect(index_of("monkey", "key"), 3);
+expect(index_of("monkey", "m"), 0);
+expect(index_of("monkey", "z"), -1);
+expect(index_of("monkey", ""), 0);
+expect(index_of("héllo wörld", "w"), 6);
+expect(index_of("日本語", "語"), 2);
+
+expect(starts_with("monkey", "mon"), true);
+expect(starts_with("monkey", "key"), false);
+expect(starts_with("日本語", "日本"), true);
+expect(ends_with("monkey", "key"), true);
+expect(ends_with("monkey", "mon"), false);
+expect(ends_with("", ""), true);
+
+// The results are real booleans, conditions can use them
+let found = 0;
+if (contains("abc", "b")) { found = 1; }
+expect(found, 1);
+expect(!starts_with("abc", "b"), true);
+
+expect(fails(fn() { contains("a", 1); }), "argument 2 to `contains` must be STRING, got INTEGER");
+expect(fails(fn() { index_of(["a"], "a"); }), "argument 1 to `index_of` must be STRING, got ARRAY");
+expect(fails(fn() { ends_with("a"); }), "wrong number of arguments: want=2, got=1");
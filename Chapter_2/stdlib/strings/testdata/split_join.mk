@@ -0,0 +1,31 @@
+// split and join
+
+let strings = import "strings";
+let split = strings["split"];
+let join = strings["join"];
+
+let fails = fn(f) {
+	let caught = 0;
+	try { f(); } catch (e) { caught = e["message"]; }
+	caught
+};
+
+expect(split("a,b,c", ","), ["a", "b", "c"]);
+expect(split("a, b, c", ", "), ["a", "b", "c"]);
+expect(split("abc", ","), ["abc"]);
+expect(split("", ","), [""]);
+expect(split(",a,", ","), ["", "a", ""]);
+expect(len(split("héllo", "")), 5);
+expect(split("日本語", ""), ["日", "本", "語"]);
+expect(split("α→β→γ", "→"), ["α", "β", "γ"]);
+
+expect(join(["a", "b", "c"], ", "), "a, b, c");
+expect(join([], ","), "");
+expect(join(["only"], ","), "only");
+expect(join(split("日本語", ""), "·"), "日·本·語");
+expect(join(split("a b c", " "), " "), "a b c");
+
+expect(fails(fn() { split(1, ","); }), "argument 1 to `split` must be STRING, got INTEGER");
+expect(fails(fn() { split("a"); }), "wrong number of arguments: want=2, got=1");
+expect(fails(fn() { join("a", ","); }), "argument 1 to `join` must be ARRAY, got STRING");
+expect(fails(fn() { join(["a", 1], ","); }), "element 1 of the array to `join` must be STRING, got INTEGER");
@@ -0,0 +1,45 @@
+// trim, replace, upper, lower and repeat
+
+let strings = import "strings";
+let trim = strings["trim"];
+let replace = strings["replace"];
+let upper = strings["upper"];
+let lower = strings["lower"];
+let repeat = strings["repeat"];
+
+let fails = fn(f) {
+	let caught = 0;
+	try { f(); } catch (e) { caught = e["message"]; }
+	caught
+};
+
+expect(trim("  monkey  "), "monkey");
+expect(trim("\t\nmonkey\n"), "monkey");
+expect(trim("   "), "");
+expect(trim(" a b "), "a b");
+
+expect(replace("a-b-c", "-", "+"), "a+b+c");
+expect(replace("aaa", "a", "bb"), "bbbbbb");
+expect(replace("monkey", "z", "y"), "monkey");
+expect(replace("café café", "é", "e"), "cafe cafe");
+
+expect(upper("monkey"), "MONKEY");
+expect(upper("σας"), "ΣΑΣ");
+expect(upper("héllo"), "HÉLLO");
+expect(lower("MONKEY"), "monkey");
+expect(lower("ÀÉÎ"), "àéî");
+expect(lower("ΣΑΣ"), "σασ");
+
+expect(repeat("ab", 3), "ababab");
+expect(repeat("ab", 0), "");
+expect(repeat("", 1000), "");
+expect(repeat("日", 2), "日日");
+expect(len(repeat("é", 4)), 4);
+
+expect(fails(fn() { trim(1); }), "argument to `trim` must be STRING, got INTEGER");
+expect(fails(fn() { replace("a", "b"); }), "wrong number of arguments: want=3, got=2");
+expect(fails(fn() { upper(true); }), "argument to `upper` must be STRING, got BOOLEAN");
+expect(fails(fn() { repeat("a", -1); }), "negative count to `repeat`: -1");
+expect(fails(fn() { repeat("a", "b"); }), "argument 2 to `repeat` must be INTEGER, got STRING");
+expect(fails(fn() { repeat("ab", 1000000000); }), "count to `repeat` too large: 1000000000");
+expect(fails(fn() { repeat("a", 100000000000000000000); }), "count to `repeat` too large: 100000000000000000000");
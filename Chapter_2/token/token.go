@@ -5,10 +5,13 @@ import "fmt"
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT"
 
 	// Identifiers
 	VARIABLE = "VAR"
 	INT      = "INT"
+	FLOAT    = "FLOAT"
+	STRING   = "STRING"
 
 	// Operators
 	PLUS   = "+"
@@ -21,11 +24,17 @@ const (
 	EQ     = "=="
 	NEQ    = "!="
 
+	PLUSASSIGN  = "+="
+	MINUSASSIGN = "-="
+	MULTASSIGN  = "*="
+	DIVASSIGN   = "/="
+
 	EXCLAMATION = "!"
 
 	// Delimiter
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
 
 	// Bracket
 	SLBRACKET = "["
@@ -44,6 +53,17 @@ const (
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	RETURN   = "RETURN"
+	THROW    = "THROW"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
 )
 
 // Alias for string
@@ -52,17 +72,30 @@ type TokenType string
 type Token struct {
 	Type    TokenType // A token contains a TokenType type (what it represents)
 	Literal string    // A token contains the string representing it
+	Line    int       // The line the token starts on, counting from 1
+	Column  int       // The column the token starts on, counting from 1
 }
 
 // A map of keywords to its tokentype
 var Keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"if":     IF,
-	"else":   ELSE,
-	"true":   TRUE,
-	"false":  FALSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"if":       IF,
+	"else":     ELSE,
+	"true":     TRUE,
+	"false":    FALSE,
+	"return":   RETURN,
+	"throw":    THROW,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"import":   IMPORT,
+	"export":   EXPORT,
 }
 
 // A function to print the token type and its literal
@@ -70,6 +103,11 @@ func (token *Token) PrintToken() {
 	fmt.Printf("Token:\nType: %s\nLiteral: %s\n", token.Type, token.Literal)
 }
 
+// A function to return the position of the token as "line:column"
+func (token *Token) Position() string {
+	return fmt.Sprintf("%d:%d", token.Line, token.Column)
+}
+
 // A function to look up all the current keyword and return tokentype
 // return token VARIABLE if not a keyword
 func LookUpKeyword(word string) TokenType {